@@ -6,8 +6,13 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/newrelic/tutone/internal/util"
+	"github.com/newrelic/tutone/pkg/config"
 	"github.com/newrelic/tutone/pkg/fetch"
 	"github.com/newrelic/tutone/pkg/generate"
+	"github.com/newrelic/tutone/pkg/mock"
+	"github.com/newrelic/tutone/pkg/query"
+	"github.com/newrelic/tutone/pkg/templates"
+	"github.com/newrelic/tutone/pkg/validate"
 )
 
 var (
@@ -52,8 +57,13 @@ func init() {
 	util.LogIfError(log.ErrorLevel, viper.BindPFlag("log_level", Command.PersistentFlags().Lookup("loglevel")))
 
 	// Add sub commands
+	Command.AddCommand(config.Command)
 	Command.AddCommand(fetch.Command)
 	Command.AddCommand(generate.Command)
+	Command.AddCommand(mock.Command)
+	Command.AddCommand(query.Command)
+	Command.AddCommand(templates.Command)
+	Command.AddCommand(validate.Command)
 }
 
 func initConfig() {
@@ -47,7 +47,7 @@ func getReadCommandMetadata(s *schema.Schema, queryPath []string) (*schema.Field
 	return nil, fmt.Errorf("could not find matching introspection data for provided query path")
 }
 
-func hydrateCommand(s *schema.Schema, command config.Command, pkgConfig *config.PackageConfig) lang.Command {
+func hydrateCommand(s *schema.Schema, command config.Command, pkgConfig *config.PackageConfig) (lang.Command, error) {
 	isBaseCommand := true
 	cmdVarName := "Command"
 
@@ -66,7 +66,7 @@ func hydrateCommand(s *schema.Schema, command config.Command, pkgConfig *config.
 	}
 
 	if len(command.Subcommands) == 0 {
-		return lang.Command{}
+		return lang.Command{}, nil
 	}
 
 	cmd.Subcommands = make([]lang.Command, len(command.Subcommands))
@@ -88,11 +88,14 @@ func hydrateCommand(s *schema.Schema, command config.Command, pkgConfig *config.
 		if err != nil {
 			subcommandMetadata, err = getReadCommandMetadata(s, subCmdConfig.GraphQLPath)
 			if err != nil {
-				log.Fatal(err)
+				return lang.Command{}, fmt.Errorf("command %s: %s", subCmdConfig.Name, err)
 			}
 		}
 
-		subcommand := hydrateSubcommand(s, subcommandMetadata, subCmdConfig)
+		subcommand, err := hydrateSubcommand(s, subcommandMetadata, subCmdConfig)
+		if err != nil {
+			return lang.Command{}, err
+		}
 
 		exampleData := lang.CommandExampleData{
 			CLIName:     "newrelic",
@@ -106,7 +109,7 @@ func hydrateCommand(s *schema.Schema, command config.Command, pkgConfig *config.
 		if subCmdConfig.Example == "" {
 			sCmdExample, err := generateCommandExample(subcommandMetadata, exampleData)
 			if err != nil {
-				log.Fatal(err)
+				return lang.Command{}, fmt.Errorf("command %s: %s", subCmdConfig.Name, err)
 			}
 
 			subcommand.Example = sCmdExample
@@ -115,12 +118,12 @@ func hydrateCommand(s *schema.Schema, command config.Command, pkgConfig *config.
 		cmd.Subcommands[i] = *subcommand
 	}
 
-	return cmd
+	return cmd, nil
 }
 
 // TODO: Consolidate common parts of hydrateCommand, hydrateSubcommand
-func hydrateSubcommand(s *schema.Schema, sCmd *schema.Field, cmdConfig config.Command) *lang.Command {
-	flags := hydrateFlagsFromSchema(sCmd.Args, cmdConfig)
+func hydrateSubcommand(s *schema.Schema, sCmd *schema.Field, cmdConfig config.Command) (*lang.Command, error) {
+	flags := hydrateFlagsFromSchema(s, sCmd.Args, cmdConfig)
 
 	var err error
 	var clientMethodArgs []string
@@ -135,7 +138,7 @@ func hydrateSubcommand(s *schema.Schema, sCmd *schema.Field, cmdConfig config.Co
 		if f.IsEnumType {
 			varName, err = wrapEnumTypeVariable(varName, f.ClientType)
 			if err != nil {
-				log.Fatal(err)
+				return nil, fmt.Errorf("command %s: %s", cmdConfig.Name, err)
 			}
 		}
 
@@ -164,7 +167,7 @@ func hydrateSubcommand(s *schema.Schema, sCmd *schema.Field, cmdConfig config.Co
 		Flags:            flags,
 	}
 
-	return &cmdResult
+	return &cmdResult, nil
 }
 
 // Returns a string representation of a variable wrapped/typed with an enum type ref
@@ -189,7 +192,7 @@ func wrapEnumTypeVariable(varName string, clientTypeRefString string) (string, e
 	return codegen.RenderTemplate(varName, t, data)
 }
 
-func hydrateFlagsFromSchema(args []schema.Field, cmdConfig config.Command) []lang.CommandFlag {
+func hydrateFlagsFromSchema(s *schema.Schema, args []schema.Field, cmdConfig config.Command) []lang.CommandFlag {
 	var flags []lang.CommandFlag
 
 	for _, arg := range args {
@@ -231,12 +234,34 @@ func hydrateFlagsFromSchema(args []schema.Field, cmdConfig config.Command) []lan
 			IsEnumType:     arg.IsEnum(),
 		}
 
+		if flag.IsEnumType {
+			flag.EnumValues = getEnumValues(s, arg.Type.GetTypeName())
+		}
+
 		flags = append(flags, flag)
 	}
 
 	return flags
 }
 
+// getEnumValues returns the names of the values of an enum type, used to
+// complete the flag and to list the values accepted by IsValid, which include
+// the deprecated values.
+func getEnumValues(s *schema.Schema, typeName string) []string {
+	enumType, err := s.LookupTypeByName(typeName)
+	if err != nil {
+		log.Warn(err)
+		return nil
+	}
+
+	var values []string
+	for _, v := range enumType.EnumValues {
+		values = append(values, v.GetName())
+	}
+
+	return values
+}
+
 func fetchRemoteTemplate(url string) (string, error) {
 	resp, err := http.Get(url)
 	if err != nil {
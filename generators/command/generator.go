@@ -14,6 +14,9 @@ import (
 
 type Generator struct {
 	lang.CommandGenerator
+	// Output is where the files are written, shared by the generators of a
+	// run.
+	Output codegen.Output
 }
 
 func (g *Generator) Generate(s *schema.Schema, genConfig *config.GeneratorConfig, pkgConfig *config.PackageConfig) error {
@@ -24,7 +27,12 @@ func (g *Generator) Generate(s *schema.Schema, genConfig *config.GeneratorConfig
 
 	cmds := make([]lang.Command, len(pkgConfig.Commands))
 	for i, c := range pkgConfig.Commands {
-		cmds[i] = hydrateCommand(s, c, pkgConfig)
+		cmd, err := hydrateCommand(s, c, pkgConfig)
+		if err != nil {
+			return err
+		}
+
+		cmds[i] = cmd
 	}
 
 	g.Commands = cmds
@@ -76,7 +84,7 @@ func (g *Generator) Execute(genConfig *config.GeneratorConfig, pkgConfig *config
 			return fmt.Errorf("generator configuration error: please set `templateDir` or `templateURL`, but not both")
 		}
 
-		templateDir := "templates/command"
+		var templateDir string
 		if hasTemplateDir {
 			templateDir, err = codegen.RenderStringFromGenerator(genConfig.TemplateDir, g)
 			if err != nil {
@@ -85,10 +93,14 @@ func (g *Generator) Execute(genConfig *config.GeneratorConfig, pkgConfig *config
 		}
 
 		c := codegen.CodeGen{
-			TemplateDir:     templateDir,
-			TemplateName:    templateName,
-			DestinationFile: destinationFile,
-			DestinationDir:  destinationPath,
+			TemplateDir:        templateDir,
+			BuiltinTemplateDir: "command",
+			TemplateName:       templateName,
+			DestinationFile:    destinationFile,
+			DestinationDir:     destinationPath,
+			GeneratorName:      genConfig.Name,
+			PackageName:        pkgConfig.Name,
+			Output:             g.Output,
 		}
 
 		if templateStr != "" {
@@ -2,7 +2,7 @@ package nerdgraphclient
 
 import (
 	"fmt"
-	"os"
+	"path"
 
 	log "github.com/sirupsen/logrus"
 
@@ -14,6 +14,15 @@ import (
 
 type Generator struct {
 	lang.GolangGenerator
+	// Expansion shares the expanded types with the other generators of the
+	// package, when set.
+	Expansion *schema.Expansion
+	// Validator shares the validator of the schema with the other packages,
+	// when set.
+	Validator *schema.SharedValidator
+	// Output is where the files are written, shared by the generators of a
+	// run.
+	Output codegen.Output
 }
 
 func (g *Generator) Generate(s *schema.Schema, genConfig *config.GeneratorConfig, pkgConfig *config.PackageConfig) error {
@@ -25,7 +34,7 @@ func (g *Generator) Generate(s *schema.Schema, genConfig *config.GeneratorConfig
 		return fmt.Errorf("unable to Generate with nil pkgConfig")
 	}
 
-	expandedTypes, err := schema.ExpandTypes(s, pkgConfig)
+	expandedTypes, err := g.Expansion.Types(s, pkgConfig)
 	if err != nil {
 		log.Error(err)
 	}
@@ -44,7 +53,7 @@ func (g *Generator) Generate(s *schema.Schema, genConfig *config.GeneratorConfig
 	// lang.Normalize(&g, genConfig, pkgConfig)
 
 	g.PackageName = pkgConfig.Name
-	g.Imports = pkgConfig.Imports
+	g.Imports = lang.GenerateGoImportsForPackage(pkgConfig)
 
 	if structsForGen != nil {
 		g.Types = *structsForGen
@@ -62,7 +71,18 @@ func (g *Generator) Generate(s *schema.Schema, genConfig *config.GeneratorConfig
 		g.Interfaces = *interfacesForGen
 	}
 
-	mutationsForGen, err := lang.GenerateGoMethodMutationsForPackage(s, genConfig, pkgConfig)
+	selectionsForGen := lang.GenerateGoSelectionsForPackage(s, pkgConfig, expandedTypes)
+	if selectionsForGen != nil {
+		g.Selections = *selectionsForGen
+	}
+
+	// A single set of fragments is shared by all of the methods in the package.
+	var fragments *schema.FragmentSet
+	if pkgConfig.QueryFragments {
+		fragments = schema.NewFragmentSet()
+	}
+
+	mutationsForGen, err := lang.GenerateGoMethodMutationsForPackage(s, genConfig, pkgConfig, fragments)
 	if err != nil {
 		return err
 	}
@@ -71,7 +91,7 @@ func (g *Generator) Generate(s *schema.Schema, genConfig *config.GeneratorConfig
 		g.Mutations = *mutationsForGen
 	}
 
-	queriesForGen, err := lang.GenerateGoMethodQueriesForPackage(s, genConfig, pkgConfig)
+	queriesForGen, err := lang.GenerateGoMethodQueriesForPackage(s, genConfig, pkgConfig, fragments)
 	if err != nil {
 		return err
 	}
@@ -80,6 +100,25 @@ func (g *Generator) Generate(s *schema.Schema, genConfig *config.GeneratorConfig
 		g.Queries = *queriesForGen
 	}
 
+	fragmentsForGen := lang.GenerateGoFragmentsForPackage(fragments)
+	if fragmentsForGen != nil {
+		g.Fragments = *fragmentsForGen
+	}
+
+	// Ensure every generated operation is valid for the schema before rendering.
+	validator, err := g.Validator.Validator(s)
+	if err != nil {
+		return err
+	}
+
+	var methods []lang.GoMethod
+	methods = append(methods, g.Mutations...)
+	methods = append(methods, g.Queries...)
+
+	if err := lang.ValidateGoMethods(validator, methods, g.Fragments); err != nil {
+		return fmt.Errorf("generated operations for package %s failed validation: %s", pkgConfig.Name, err)
+	}
+
 	return nil
 }
 
@@ -92,12 +131,6 @@ func (g *Generator) Execute(genConfig *config.GeneratorConfig, pkgConfig *config
 		destinationPath = pkgConfig.Path
 	}
 
-	if _, err = os.Stat(destinationPath); os.IsNotExist(err) {
-		if err = os.Mkdir(destinationPath, 0755); err != nil {
-			log.Error(err)
-		}
-	}
-
 	// Default file name is 'nerdgraph.go'
 	fileName := "nerdgraphclient.go"
 	if genConfig.FileName != "" {
@@ -120,7 +153,7 @@ func (g *Generator) Execute(genConfig *config.GeneratorConfig, pkgConfig *config
 		return err
 	}
 
-	templateDir := "templates/nerdgraphclient"
+	var templateDir string
 	if genConfig.TemplateDir != "" {
 		templateDir, err = codegen.RenderStringFromGenerator(genConfig.TemplateDir, g)
 		if err != nil {
@@ -129,11 +162,28 @@ func (g *Generator) Execute(genConfig *config.GeneratorConfig, pkgConfig *config
 	}
 
 	c := codegen.CodeGen{
-		TemplateDir:     templateDir,
-		TemplateName:    templateName,
-		DestinationFile: filePath,
-		DestinationDir:  destinationPath,
+		TemplateDir:        templateDir,
+		BuiltinTemplateDir: "nerdgraphclient",
+		TemplateName:       templateName,
+		DestinationFile:    filePath,
+		DestinationDir:     destinationPath,
+		GeneratorName:      genConfig.Name,
+		PackageName:        pkgConfig.Name,
+		Output:             g.Output,
+	}
+
+	goFiles, err := lang.SplitGolangGenerator(g.GolangGenerator, genConfig.Split)
+	if err != nil {
+		return err
+	}
+
+	files := make([]codegen.File, len(goFiles))
+	for i, f := range goFiles {
+		files[i] = codegen.File{
+			Name:      f.FileName(path.Base(filePath)),
+			Generator: &Generator{GolangGenerator: f.Generator},
+		}
 	}
 
-	return c.WriteFile(g)
+	return c.WriteFiles(files)
 }
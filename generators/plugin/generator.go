@@ -0,0 +1,129 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/newrelic/tutone/internal/codegen"
+	"github.com/newrelic/tutone/internal/config"
+	"github.com/newrelic/tutone/internal/schema"
+	sdk "github.com/newrelic/tutone/pkg/plugin"
+)
+
+// Generator runs the external executable configured as the plugin of the
+// generator, writing the files it returns.
+type Generator struct {
+	Files []sdk.File
+	// Output is where the files are written, shared by the generators of a
+	// run.
+	Output codegen.Output
+}
+
+func (g *Generator) Generate(s *schema.Schema, genConfig *config.GeneratorConfig, pkgConfig *config.PackageConfig) error {
+	if genConfig == nil {
+		return fmt.Errorf("unable to generate, missing generator config")
+	}
+
+	if pkgConfig == nil {
+		return fmt.Errorf("unable to generate, missing package config")
+	}
+
+	resp, err := Run(genConfig.Plugin, &sdk.Request{
+		Version:   sdk.ProtocolVersion,
+		Schema:    s,
+		Generator: genConfig,
+		Package:   pkgConfig,
+	})
+	if err != nil {
+		return err
+	}
+
+	g.Files = resp.Files
+
+	return nil
+}
+
+func (g *Generator) Execute(genConfig *config.GeneratorConfig, pkgConfig *config.PackageConfig) error {
+	destinationPath := pkgConfig.GetDestinationPath()
+
+	for _, f := range g.Files {
+		if err := validateFileName(f.Name); err != nil {
+			return fmt.Errorf("plugin %s: %s", genConfig.Plugin, err)
+		}
+
+		filePath := path.Join(destinationPath, filepath.ToSlash(f.Name))
+
+		c := codegen.CodeGen{
+			DestinationFile: filePath,
+			DestinationDir:  path.Dir(filePath),
+			GeneratorName:   genConfig.Name,
+			PackageName:     pkgConfig.Name,
+			Output:          g.Output,
+		}
+
+		if err := c.WriteContent("plugin "+genConfig.Plugin, []byte(f.Content)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Run executes the plugin with the Request, returning its Response.
+func Run(executable string, req *sdk.Request) (*sdk.Response, error) {
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout bytes.Buffer
+
+	cmd := exec.Command(executable)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+
+	log.WithFields(log.Fields{
+		"plugin": executable,
+	}).Debug("running plugin")
+
+	runErr := cmd.Run()
+
+	var resp sdk.Response
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		if runErr != nil {
+			return nil, fmt.Errorf("plugin %s failed: %s", executable, runErr)
+		}
+
+		return nil, fmt.Errorf("plugin %s returned an invalid response: %s", executable, err)
+	}
+
+	if resp.Error != "" {
+		return nil, fmt.Errorf("plugin %s failed: %s", executable, resp.Error)
+	}
+
+	if runErr != nil {
+		return nil, fmt.Errorf("plugin %s failed: %s", executable, runErr)
+	}
+
+	return &resp, nil
+}
+
+// validateFileName ensures the file is within the package.
+func validateFileName(name string) error {
+	clean := filepath.Clean(name)
+
+	if name == "" || filepath.IsAbs(name) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("invalid file name %q, must be relative to the package path", name)
+	}
+
+	return nil
+}
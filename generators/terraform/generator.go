@@ -1,16 +1,87 @@
 package terraform
 
 import (
+	"fmt"
+
 	log "github.com/sirupsen/logrus"
 
+	"github.com/newrelic/tutone/internal/codegen"
+	"github.com/newrelic/tutone/internal/config"
 	"github.com/newrelic/tutone/internal/schema"
+	"github.com/newrelic/tutone/pkg/lang"
 )
 
+// Generator generates the schema and CRUD functions of the Terraform resources
+// configured for a package.
 type Generator struct {
+	lang.TerraformGenerator
+	// Output is where the files are written, shared by the generators of a
+	// run.
+	Output codegen.Output
 }
 
-func (g *Generator) Generate(s *schema.Schema) error {
-	log.Debugf("s: %+v", *s)
+func (g *Generator) Generate(s *schema.Schema, genConfig *config.GeneratorConfig, pkgConfig *config.PackageConfig) error {
+	if genConfig == nil {
+		return fmt.Errorf("unable to Generate with nil genConfig")
+	}
+
+	if pkgConfig == nil {
+		return fmt.Errorf("unable to Generate with nil pkgConfig")
+	}
+
+	if len(pkgConfig.Resources) == 0 {
+		log.WithFields(log.Fields{
+			"package": pkgConfig.Name,
+		}).Warn("no resources configured for the terraform generator")
+	}
+
+	terraformGenerator, err := lang.GenerateTerraformForPackage(s, pkgConfig)
+	if err != nil {
+		return fmt.Errorf("package %s: %w", pkgConfig.Name, err)
+	}
+
+	g.TerraformGenerator = *terraformGenerator
 
 	return nil
 }
+
+func (g *Generator) Execute(genConfig *config.GeneratorConfig, pkgConfig *config.PackageConfig) error {
+	destinationPath := pkgConfig.GetDestinationPath()
+
+	// Default file name is 'resources.go'
+	fileName := "resources.go"
+	if genConfig.FileName != "" {
+		fileName = genConfig.FileName
+	}
+
+	templateName := "resources.go.tmpl"
+	if genConfig.TemplateName != "" {
+		templateName = genConfig.TemplateName
+	}
+
+	filePath, err := codegen.RenderStringFromGenerator(fmt.Sprintf("%s/%s", destinationPath, fileName), g)
+	if err != nil {
+		return err
+	}
+
+	var templateDir string
+	if genConfig.TemplateDir != "" {
+		templateDir, err = codegen.RenderStringFromGenerator(genConfig.TemplateDir, g)
+		if err != nil {
+			return err
+		}
+	}
+
+	c := codegen.CodeGen{
+		TemplateDir:        templateDir,
+		BuiltinTemplateDir: "terraform",
+		TemplateName:       templateName,
+		DestinationFile:    filePath,
+		DestinationDir:     destinationPath,
+		GeneratorName:      genConfig.Name,
+		PackageName:        pkgConfig.Name,
+		Output:             g.Output,
+	}
+
+	return c.WriteFile(g)
+}
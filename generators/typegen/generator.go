@@ -2,7 +2,7 @@ package typegen
 
 import (
 	"fmt"
-	"os"
+	"path"
 
 	log "github.com/sirupsen/logrus"
 
@@ -14,6 +14,12 @@ import (
 
 type Generator struct {
 	lang.GolangGenerator
+	// Expansion shares the expanded types with the other generators of the
+	// package, when set.
+	Expansion *schema.Expansion
+	// Output is where the files are written, shared by the generators of a
+	// run.
+	Output codegen.Output
 }
 
 // Generate is the entry point for this Generator.
@@ -26,7 +32,7 @@ func (g *Generator) Generate(s *schema.Schema, genConfig *config.GeneratorConfig
 		return fmt.Errorf("unable to Generate with nil pkgConfig")
 	}
 
-	expandedTypes, err := schema.ExpandTypes(s, pkgConfig)
+	expandedTypes, err := g.Expansion.Types(s, pkgConfig)
 	if err != nil {
 		log.Error(err)
 	}
@@ -38,7 +44,7 @@ func (g *Generator) Generate(s *schema.Schema, genConfig *config.GeneratorConfig
 
 	// The Execute() below expects to have Generator g populated for use in the template files.
 	g.PackageName = pkgConfig.Name
-	g.Imports = pkgConfig.Imports
+	g.Imports = lang.GenerateGoImportsForPackage(pkgConfig)
 
 	if structsForGen != nil {
 		g.Types = *structsForGen
@@ -56,6 +62,11 @@ func (g *Generator) Generate(s *schema.Schema, genConfig *config.GeneratorConfig
 		g.Interfaces = *interfacesForGen
 	}
 
+	selectionsForGen := lang.GenerateGoSelectionsForPackage(s, pkgConfig, expandedTypes)
+	if selectionsForGen != nil {
+		g.Selections = *selectionsForGen
+	}
+
 	return nil
 }
 
@@ -69,12 +80,6 @@ func (g *Generator) Execute(genConfig *config.GeneratorConfig, pkgConfig *config
 		destinationPath = pkgConfig.Path
 	}
 
-	if _, err = os.Stat(destinationPath); os.IsNotExist(err) {
-		if err = os.Mkdir(destinationPath, 0755); err != nil {
-			log.Error(err)
-		}
-	}
-
 	// Default file name is 'types.go'
 	fileName := "types.go"
 	if genConfig.FileName != "" {
@@ -82,28 +87,35 @@ func (g *Generator) Execute(genConfig *config.GeneratorConfig, pkgConfig *config
 	}
 
 	filePath := fmt.Sprintf("%s/%s", destinationPath, fileName)
-	file, err := os.Create(filePath)
-	if err != nil {
-		log.Error(err)
-	}
-	defer file.Close()
 
 	templateName := "types.go.tmpl"
 	if genConfig.TemplateName != "" {
 		templateName = genConfig.TemplateName
 	}
 
-	templateDir := "templates/typegen"
-	if genConfig.TemplateDir != "" {
-		templateDir = genConfig.TemplateDir
+	c := codegen.CodeGen{
+		TemplateDir:        genConfig.TemplateDir,
+		BuiltinTemplateDir: "typegen",
+		TemplateName:       templateName,
+		DestinationFile:    filePath,
+		DestinationDir:     destinationPath,
+		GeneratorName:      genConfig.Name,
+		PackageName:        pkgConfig.Name,
+		Output:             g.Output,
 	}
 
-	c := codegen.CodeGen{
-		TemplateDir:     templateDir,
-		TemplateName:    templateName,
-		DestinationFile: filePath,
-		DestinationDir:  destinationPath,
+	goFiles, err := lang.SplitGolangGenerator(g.GolangGenerator, genConfig.Split)
+	if err != nil {
+		return err
+	}
+
+	files := make([]codegen.File, len(goFiles))
+	for i, f := range goFiles {
+		files[i] = codegen.File{
+			Name:      f.FileName(path.Base(filePath)),
+			Generator: &Generator{GolangGenerator: f.Generator},
+		}
 	}
 
-	return c.WriteFile(g)
+	return c.WriteFiles(files)
 }
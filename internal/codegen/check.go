@@ -0,0 +1,119 @@
+package codegen
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// The reasons a file differs from the generated code.
+const (
+	// CheckModified is a file with contents that differ from the generated code.
+	CheckModified = "modified"
+	// CheckMissing is generated code without a file.
+	CheckMissing = "missing"
+	// CheckStale is a previously generated file that would be removed.
+	CheckStale = "stale"
+)
+
+// CheckResult is a file which differs from the generated code.
+type CheckResult struct {
+	Path   string
+	Status string
+	// Diff is a unified diff from the file to the generated code, empty for
+	// stale files.
+	Diff string
+}
+
+// Check compares the generated code with the files on disk, in place of
+// writing the files.
+type Check struct {
+	mu      sync.Mutex
+	results []CheckResult
+}
+
+// Results returns the files which differ from the generated code, sorted by
+// path.
+func (c *Check) Results() []CheckResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	results := make([]CheckResult, len(c.results))
+	copy(results, c.results)
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Path < results[j].Path
+	})
+
+	return results
+}
+
+// Compare records a difference when the file does not match the content.
+func (c *Check) Compare(file string, content []byte) error {
+	file = filepath.Clean(file)
+
+	existing, err := ioutil.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err == nil && string(existing) == string(content) {
+		return nil
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(existing),
+		B:        splitLines(content),
+		FromFile: "a/" + filepath.ToSlash(file),
+		ToFile:   "b/" + filepath.ToSlash(file),
+		Context:  3,
+	})
+	if err != nil {
+		return err
+	}
+
+	status := CheckModified
+	if existing == nil {
+		status = CheckMissing
+	}
+
+	c.add(CheckResult{Path: file, Status: status, Diff: diff})
+
+	return nil
+}
+
+// Stale records a previously generated file that would be removed.
+func (c *Check) Stale(file string) {
+	c.add(CheckResult{Path: filepath.Clean(file), Status: CheckStale})
+}
+
+// add records the result, unless the file was already recorded, as a stale
+// file can be found both by WriteFiles and the manifest.
+func (c *Check) add(result CheckResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, r := range c.results {
+		if r.Path == result.Path {
+			return
+		}
+	}
+
+	c.results = append(c.results, result)
+}
+
+// splitLines splits the content into lines for a diff, each keeping its
+// newline.
+func splitLines(content []byte) []string {
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
@@ -0,0 +1,72 @@
+//go:build unit
+// +build unit
+
+package codegen
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeGen_WriteFiles_Check(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "tutone-check")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	m := &Manifest{}
+
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "types.go.tmpl"), []byte(testTemplate), 0644))
+
+	c := CodeGen{
+		TemplateDir:     dir,
+		TemplateName:    "types.go.tmpl",
+		DestinationDir:  dir,
+		DestinationFile: path.Join(dir, "types.go"),
+		GeneratorName:   "typegen",
+		PackageName:     "test",
+		Output:          Output{Manifest: m},
+	}
+
+	err = c.WriteFiles([]File{
+		{Name: "types_a.go", Generator: &testGenerator{PackageName: "test", Names: []string{"A"}}},
+		{Name: "types_b.go", Generator: &testGenerator{PackageName: "test", Names: []string{"B"}}},
+		{Name: "types_c.go", Generator: &testGenerator{PackageName: "test", Names: []string{"C"}}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, m.Prune([]string{"test"}, []string{"test"}, nil))
+
+	check := &Check{}
+	c.Check = check
+
+	err = c.WriteFiles([]File{
+		{Name: "types_a.go", Generator: &testGenerator{PackageName: "test", Names: []string{"A"}}},
+		{Name: "types_b.go", Generator: &testGenerator{PackageName: "test", Names: []string{"B", "D"}}},
+		{Name: "types_d.go", Generator: &testGenerator{PackageName: "test", Names: []string{"D"}}},
+	})
+	require.NoError(t, err)
+
+	// Nothing is written or removed
+	assert.NoFileExists(t, path.Join(dir, "types_d.go"))
+	assert.FileExists(t, path.Join(dir, "types_c.go"))
+
+	results := check.Results()
+	require.Len(t, results, 3)
+
+	assert.Equal(t, path.Join(dir, "types_b.go"), results[0].Path)
+	assert.Equal(t, CheckModified, results[0].Status)
+	assert.Contains(t, results[0].Diff, "\n+type D string\n")
+
+	assert.Equal(t, path.Join(dir, "types_c.go"), results[1].Path)
+	assert.Equal(t, CheckStale, results[1].Status)
+	assert.Empty(t, results[1].Diff)
+
+	assert.Equal(t, path.Join(dir, "types_d.go"), results[2].Path)
+	assert.Equal(t, CheckMissing, results[2].Status)
+}
@@ -2,25 +2,61 @@ package codegen
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
 	"text/template"
 
 	log "github.com/sirupsen/logrus"
-	"golang.org/x/tools/imports"
 
 	"github.com/newrelic/tutone/internal/output"
 	"github.com/newrelic/tutone/internal/util"
+	"github.com/newrelic/tutone/templates"
 )
 
+// GeneratedHeader is the start of the comment found at the top of every
+// generated file.
+const GeneratedHeader = "// Code generated by tutone"
+
+// BuiltinTemplatePrefix is prepended to the path of built-in templates in
+// messages.
+const BuiltinTemplatePrefix = "builtin:"
+
+// PartialsTemplateDir is the directory of the built-in partials, which are
+// available to the templates of every generator.
+const PartialsTemplateDir = "partials"
+
 type CodeGen struct {
-	TemplateDir     string
-	TemplateName    string
-	DestinationDir  string
-	DestinationFile string
-	Source          Path
-	Destination     Path
+	// TemplateDir is searched for the TemplateName before the built-in
+	// templates, so may override them.
+	TemplateDir string
+	// BuiltinTemplateDir is the directory of the built-in templates of the
+	// generator, eg: typegen
+	BuiltinTemplateDir string
+	TemplateName       string
+	DestinationDir     string
+	DestinationFile    string
+	Source             Path
+	Destination        Path
+	// GeneratorName and PackageName are recorded in the manifest for each file.
+	GeneratorName string
+	PackageName   string
+	Output
+}
+
+// Output is shared by the CodeGens of a run.  The Manifest records the files
+// written, and when the Check is set, the generated code is compared with the
+// files rather than written.  Either may be nil.
+type Output struct {
+	Manifest *Manifest
+	Check    *Check
 }
 
 type Path struct {
@@ -32,99 +68,334 @@ type Path struct {
 
 // WriteFile creates a new file, where the output from rendering template using the received Generator will be stored.
 func (c *CodeGen) WriteFile(g Generator) error {
-	var err error
+	content, err := c.render(g)
+	if err != nil {
+		return err
+	}
 
-	if _, err = os.Stat(c.DestinationDir); os.IsNotExist(err) {
-		if err = os.Mkdir(c.DestinationDir, 0755); err != nil {
-			return err
-		}
+	return c.write(content)
+}
+
+// render returns the formatted output of the template using the received
+// Generator.  When formatting fails, a FormatError is returned.
+func (c *CodeGen) render(g Generator) ([]byte, error) {
+	templateString, templatePath, err := c.readTemplate()
+	if err != nil {
+		return nil, err
 	}
 
-	file, err := os.Create(c.DestinationFile)
+	tmpl, err := c.parseTemplate(path.Base(templatePath), templateString)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	defer file.Close()
+	return c.execute(tmpl, templatePath, g)
+}
 
-	templatePath := path.Join(c.TemplateDir, c.TemplateName)
-	templateName := path.Base(templatePath)
+// execute returns the formatted output of the parsed template.  A FormatError
+// names the partial that rendered the offending line, if any.
+func (c *CodeGen) execute(tmpl *template.Template, templatePath string, g Generator) ([]byte, error) {
+	var resultBuf bytes.Buffer
 
-	tmpl, err := template.New(templateName).Funcs(util.GetTemplateFuncs()).ParseFiles(templatePath)
+	err := tmpl.Execute(&resultBuf, g)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var resultBuf bytes.Buffer
+	content, partialLines := stripPartialMarkers(resultBuf.Bytes())
+
+	formatted, err := c.format(templatePath, content)
+
+	var formatErr *FormatError
+	if errors.As(err, &formatErr) && formatErr.Line > 0 && formatErr.Line <= len(partialLines) {
+		formatErr.Partial = partialLines[formatErr.Line-1]
+	}
+
+	return formatted, err
+}
+
+// WriteContent writes content rendered without a template, such as by a
+// plugin, formatting it first when the DestinationFile is Go source.  The
+// source names where the content came from in a FormatError.
+func (c *CodeGen) WriteContent(source string, content []byte) error {
+	if path.Ext(c.DestinationFile) == ".go" {
+		formatted, err := c.format(source, content)
+		if err != nil {
+			return err
+		}
+
+		content = formatted
+	}
+
+	return c.write(content)
+}
+
+// readTemplate returns the TemplateName from the TemplateDir, falling back to
+// the built-in templates when it isn't found there, along with its path.
+func (c *CodeGen) readTemplate() (string, string, error) {
+	if c.TemplateDir != "" {
+		templatePath := path.Join(c.TemplateDir, c.TemplateName)
 
-	err = tmpl.Execute(&resultBuf, g)
+		content, err := ioutil.ReadFile(templatePath)
+		if err == nil {
+			return string(content), templatePath, nil
+		}
+
+		if !os.IsNotExist(err) || c.BuiltinTemplateDir == "" {
+			return "", "", err
+		}
+	}
+
+	templatePath := path.Join(c.BuiltinTemplateDir, c.TemplateName)
+
+	content, err := fs.ReadFile(templates.FS, templatePath)
 	if err != nil {
-		return err
+		return "", "", fmt.Errorf("template %s not found in %q or the built-in templates", c.TemplateName, c.TemplateDir)
 	}
 
-	formatted, err := imports.Process(file.Name(), resultBuf.Bytes(), nil)
+	return string(content), BuiltinTemplatePrefix + templatePath, nil
+}
+
+// parseTemplate parses the template along with the built-in partials, such as
+// "struct" and "mutation".  The files of the TemplateDir named after a
+// built-in partial, such as struct.tmpl, are parsed last, so that they
+// override it.  Other templates in the TemplateDir, such as those of another
+// generator sharing the directory, are ignored.
+func (c *CodeGen) parseTemplate(name string, templateString string) (*template.Template, error) {
+	tmpl := template.New(name).Funcs(util.GetTemplateFuncs())
+
+	partials, err := fs.Glob(templates.FS, PartialsTemplateDir+"/*.tmpl")
 	if err != nil {
-		log.Error(resultBuf.String())
+		return nil, err
+	}
 
-		_, err = file.WriteAt(resultBuf.Bytes(), 0)
+	for _, p := range partials {
+		content, err := fs.ReadFile(templates.FS, p)
 		if err != nil {
-			log.Error(err)
+			return nil, err
+		}
+
+		if err = parsePartials(tmpl, BuiltinTemplatePrefix+p, string(content)); err != nil {
+			return nil, err
 		}
 	}
 
-	_, err = file.WriteAt(formatted, 0)
-	if err != nil {
-		return err
+	if _, err = tmpl.Parse(templateString); err != nil {
+		return nil, err
 	}
 
-	output.PrintSuccessMessage(c.DestinationDir, c.DestinationFile)
+	if c.TemplateDir == "" {
+		return tmpl, nil
+	}
 
-	return nil
+	for _, p := range partials {
+		if path.Base(p) == c.TemplateName {
+			continue
+		}
+
+		o := filepath.Join(c.TemplateDir, path.Base(p))
+
+		content, err := ioutil.ReadFile(o)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err = parsePartials(tmpl, o, string(content)); err != nil {
+			return nil, err
+		}
+	}
+
+	return tmpl, nil
 }
 
-func (c *CodeGen) WriteFileFromTemplateString(g Generator, templateString string) error {
+// write stores the content in the DestinationFile, creating the
+// DestinationDir when necessary.  When a Check is set, the content is compared
+// with the DestinationFile instead.
+func (c *CodeGen) write(content []byte) error {
 	var err error
 
+	if c.Manifest != nil {
+		c.Manifest.Record(c.DestinationFile, c.GeneratorName, c.PackageName, content)
+	}
+
+	if c.Check != nil {
+		return c.Check.Compare(c.DestinationFile, content)
+	}
+
 	if _, err = os.Stat(c.DestinationDir); os.IsNotExist(err) {
-		if err = os.Mkdir(c.DestinationDir, 0755); err != nil {
+		if err = os.MkdirAll(c.DestinationDir, 0755); err != nil {
 			return err
 		}
 	}
 
-	file, err := os.Create(c.DestinationFile)
-	if err != nil {
+	if err = c.checkOverwrite(c.DestinationFile); err != nil {
 		return err
 	}
 
-	defer file.Close()
+	if err = ioutil.WriteFile(c.DestinationFile, content, 0644); err != nil {
+		return err
+	}
 
-	templatePath := path.Join(c.TemplateDir, c.TemplateName)
-	templateName := path.Base(templatePath)
+	// The output of a previous run that failed to format is no longer useful.
+	if err = os.Remove(c.DestinationFile + FailedFileSuffix); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	output.PrintSuccessMessage(c.DestinationDir, c.DestinationFile)
 
-	tmpl, err := template.New(templateName).Funcs(util.GetTemplateFuncs()).Parse(templateString)
+	return nil
+}
+
+// File is a single file written by WriteFiles.
+type File struct {
+	// Name is the name of the file within the DestinationDir.
+	Name      string
+	Generator Generator
+}
+
+// WriteFiles renders the template for each of the received files, writing
+// them to the DestinationDir.  Files that the manifest records as previously
+// written by the generator for the package, such as types_enums.go when the
+// types are no longer split, are removed when they are no longer written.
+func (c *CodeGen) WriteFiles(files []File) error {
+	for _, f := range files {
+		fileGen := *c
+		fileGen.DestinationFile = path.Join(c.DestinationDir, f.Name)
+
+		content, err := fileGen.render(f.Generator)
+		if err != nil {
+			return err
+		}
+
+		// A template may not render every part of the Generator, so a split file
+		// can end up without any declarations.
+		if len(files) > 1 && !hasDeclarations(content) {
+			continue
+		}
+
+		if err := fileGen.write(content); err != nil {
+			return err
+		}
+	}
+
+	return c.removeStaleFiles()
+}
+
+// removeStaleFiles removes the files previously written by the generator for
+// the package, which have not been written again.  Files that have been
+// modified since they were written are left for the manifest to report.
+func (c *CodeGen) removeStaleFiles() error {
+	if c.Manifest == nil {
+		return nil
+	}
+
+	for _, e := range c.Manifest.Unwritten(c.GeneratorName, c.PackageName) {
+		unchanged, err := c.Manifest.IsUnchanged(e.Path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return err
+		}
+
+		if !unchanged {
+			continue
+		}
+
+		if c.Check != nil {
+			c.Check.Stale(e.Path)
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"file": e.Path,
+		}).Info("removing stale generated file")
+
+		if err := os.Remove(e.Path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkOverwrite returns an error when the file exists, but neither has the
+// generated code header nor is unchanged since it was recorded in the manifest,
+// so may contain code that would be lost.
+func (c *CodeGen) checkOverwrite(filePath string) error {
+	generated, err := IsGeneratedFile(filePath)
 	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
 		return err
 	}
 
-	var resultBuf bytes.Buffer
+	if generated {
+		return nil
+	}
+
+	if c.Manifest != nil {
+		unchanged, err := c.Manifest.IsUnchanged(filePath)
+		if err != nil {
+			return err
+		}
+
+		if unchanged {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("refusing to overwrite %s, which does not start with the %q header", filePath, GeneratedHeader)
+}
 
-	err = tmpl.Execute(&resultBuf, g)
+// hasDeclarations determines if the Go source declares anything.  Source
+// that fails to parse is assumed to.
+func hasDeclarations(content []byte) bool {
+	file, err := parser.ParseFile(token.NewFileSet(), "", content, 0)
 	if err != nil {
-		return err
+		return true
 	}
 
-	formatted, err := imports.Process(file.Name(), resultBuf.Bytes(), nil)
+	return len(file.Decls) > 0
+}
+
+// IsGeneratedFile determines if the file starts with the header added to all
+// of the code generated by tutone.
+func IsGeneratedFile(filePath string) (bool, error) {
+	file, err := os.Open(filePath)
 	if err != nil {
-		log.Error(resultBuf.String())
-		return fmt.Errorf("failed to format buffer: %s", err)
+		return false, err
 	}
+	defer file.Close()
+
+	header := make([]byte, 512)
+	n, err := file.Read(header)
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+
+	return bytes.Contains(header[:n], []byte(GeneratedHeader)), nil
+}
 
-	_, err = file.WriteAt(formatted, 0)
+func (c *CodeGen) WriteFileFromTemplateString(g Generator, templateString string) error {
+	templatePath := path.Join(c.TemplateDir, c.TemplateName)
+	templateName := path.Base(templatePath)
+
+	tmpl, err := c.parseTemplate(templateName, templateString)
 	if err != nil {
 		return err
 	}
 
-	output.PrintSuccessMessage(c.DestinationDir, c.DestinationFile)
+	formatted, err := c.execute(tmpl, templatePath, g)
+	if err != nil {
+		return err
+	}
 
-	return nil
+	return c.write(formatted)
 }
@@ -0,0 +1,235 @@
+//go:build unit
+// +build unit
+
+package codegen
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/tutone/internal/config"
+	"github.com/newrelic/tutone/internal/schema"
+)
+
+type testGenerator struct {
+	PackageName string
+	Names       []string
+}
+
+func (g *testGenerator) Generate(*schema.Schema, *config.GeneratorConfig, *config.PackageConfig) error {
+	return nil
+}
+
+func (g *testGenerator) Execute(*config.GeneratorConfig, *config.PackageConfig) error {
+	return nil
+}
+
+const testTemplate = `// Code generated by tutone: DO NOT EDIT
+package {{ .PackageName }}
+{{ range .Names }}
+type {{ . }} string
+{{ end }}`
+
+func TestCodeGen_WriteFiles(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "tutone-codegen")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	m := &Manifest{}
+
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "types.go.tmpl"), []byte(testTemplate), 0644))
+
+	c := CodeGen{
+		TemplateDir:     dir,
+		TemplateName:    "types.go.tmpl",
+		DestinationDir:  dir,
+		DestinationFile: path.Join(dir, "types.go"),
+		GeneratorName:   "typegen",
+		PackageName:     "test",
+		Output:          Output{Manifest: m},
+	}
+
+	err = c.WriteFiles([]File{
+		{Name: "types_a.go", Generator: &testGenerator{PackageName: "test", Names: []string{"A"}}},
+		{Name: "types_b.go", Generator: &testGenerator{PackageName: "test", Names: []string{"B"}}},
+		// Nothing is declared, so the file isn't written
+		{Name: "types_c.go", Generator: &testGenerator{PackageName: "test"}},
+	})
+	require.NoError(t, err)
+
+	content, err := ioutil.ReadFile(path.Join(dir, "types_a.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "// Code generated by tutone: DO NOT EDIT\npackage test\n\ntype A string\n", string(content))
+	assert.NoFileExists(t, path.Join(dir, "types_c.go"))
+
+	// Only the files the manifest records for the generator and package are
+	// removed, even when others are named after the DestinationFile.
+	generated := "// Code generated by tutone: DO NOT EDIT\npackage test\n"
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "types_custom.go"), []byte(generated), 0644))
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "types_integration_test.go"), []byte(generated), 0644))
+	m.Record(path.Join(dir, "types_integration_test.go"), "nerdgraphclient", "test", []byte(generated))
+	require.NoError(t, m.Prune([]string{"test"}, []string{"test"}, nil))
+
+	err = c.WriteFiles([]File{
+		{Name: "types.go", Generator: &testGenerator{PackageName: "test", Names: []string{"A", "B"}}},
+	})
+	require.NoError(t, err)
+
+	assert.FileExists(t, path.Join(dir, "types.go"))
+	assert.FileExists(t, path.Join(dir, "types_custom.go"))
+	assert.FileExists(t, path.Join(dir, "types_integration_test.go"))
+	assert.NoFileExists(t, path.Join(dir, "types_a.go"))
+	assert.NoFileExists(t, path.Join(dir, "types_b.go"))
+}
+
+func TestCodeGen_WriteFile_FormatError(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "tutone-format")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tmpl := "// Code generated by tutone: DO NOT EDIT\npackage {{ .PackageName }}\n{{ range .Names }}\ntype {{ . }} struct {\n\tA string\n\tB string string\n}\n{{ end }}"
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "types.go.tmpl"), []byte(tmpl), 0644))
+
+	c := CodeGen{
+		TemplateDir:     dir,
+		TemplateName:    "types.go.tmpl",
+		DestinationDir:  dir,
+		DestinationFile: path.Join(dir, "types.go"),
+	}
+
+	existing := "// Code generated by tutone: DO NOT EDIT\npackage test\n"
+	require.NoError(t, ioutil.WriteFile(c.DestinationFile, []byte(existing), 0644))
+
+	err = c.WriteFile(&testGenerator{PackageName: "test", Names: []string{"Broken"}})
+	require.Error(t, err)
+
+	var formatErr *FormatError
+	require.ErrorAs(t, err, &formatErr)
+	assert.Equal(t, path.Join(dir, "types.go.tmpl"), formatErr.Template)
+	assert.Empty(t, formatErr.Partial)
+	assert.Equal(t, 6, formatErr.Line)
+	assert.Equal(t, "\tB string string", formatErr.Source)
+	assert.Equal(t, "Broken", formatErr.Type)
+	assert.Equal(t, c.DestinationFile+FailedFileSuffix, formatErr.FailedFile)
+
+	// The destination is left untouched, and the output kept for debugging
+	content, err := ioutil.ReadFile(c.DestinationFile)
+	require.NoError(t, err)
+	assert.Equal(t, existing, string(content))
+
+	failed, err := ioutil.ReadFile(formatErr.FailedFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(failed), "B string string")
+
+	// A successful run removes the failed output
+	require.NoError(t, c.WriteFile(&testGenerator{PackageName: "test"}))
+	assert.NoFileExists(t, formatErr.FailedFile)
+
+	// The output of an overridden partial points at the partial
+	tmpl = "// Code generated by tutone: DO NOT EDIT\npackage {{ .PackageName }}\n{{ range .Names }}{{ template \"struct\" . }}{{ end }}"
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "types.go.tmpl"), []byte(tmpl), 0644))
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "struct.tmpl"), []byte("{{ define \"struct\" }}\ntype {{ . }} struct {\n\tA string string\n}\n{{ end }}"), 0644))
+
+	err = c.WriteFile(&testGenerator{PackageName: "test", Names: []string{"Broken"}})
+	require.ErrorAs(t, err, &formatErr)
+	assert.Equal(t, path.Join(dir, "types.go.tmpl"), formatErr.Template)
+	assert.Equal(t, path.Join(dir, "struct.tmpl"), formatErr.Partial)
+	assert.Equal(t, "\tA string string", formatErr.Source)
+	assert.Contains(t, err.Error(), "rendered from "+path.Join(dir, "types.go.tmpl")+" using partial "+path.Join(dir, "struct.tmpl")+": line 5")
+
+	failed, err = ioutil.ReadFile(formatErr.FailedFile)
+	require.NoError(t, err)
+	assert.NotContains(t, string(failed), "\x1e")
+}
+
+func TestCodeGen_WriteFile_BuiltinTemplates(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "tutone-builtin")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := CodeGen{
+		TemplateDir:        dir,
+		BuiltinTemplateDir: "typegen",
+		TemplateName:       "types.go.tmpl",
+		DestinationDir:     dir,
+		DestinationFile:    path.Join(dir, "types.go"),
+	}
+
+	// The TemplateDir doesn't have the template, so the built-in one is used
+	content, templatePath, err := c.readTemplate()
+	require.NoError(t, err)
+	assert.Equal(t, BuiltinTemplatePrefix+"typegen/types.go.tmpl", templatePath)
+	assert.Contains(t, content, "{{- range .Types }}")
+
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "types.go.tmpl"), []byte(testTemplate), 0644))
+
+	content, templatePath, err = c.readTemplate()
+	require.NoError(t, err)
+	assert.Equal(t, path.Join(dir, "types.go.tmpl"), templatePath)
+	assert.Equal(t, testTemplate, content)
+
+	c.TemplateName = "missing.go.tmpl"
+	_, _, err = c.readTemplate()
+	assert.Error(t, err)
+}
+
+func TestCodeGen_WriteFile_Partials(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "tutone-partials")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tmpl := "// Code generated by tutone: DO NOT EDIT\npackage {{ .PackageName }}\n{{ range .Names }}{{ template \"struct\" . }}{{ end }}"
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "types.go.tmpl"), []byte(tmpl), 0644))
+
+	c := CodeGen{
+		TemplateDir:     dir,
+		TemplateName:    "types.go.tmpl",
+		DestinationDir:  dir,
+		DestinationFile: path.Join(dir, "types.go"),
+	}
+
+	// The built-in partials are available to every template
+	templateString, _, err := c.readTemplate()
+	require.NoError(t, err)
+
+	parsed, err := c.parseTemplate("types.go.tmpl", templateString)
+	require.NoError(t, err)
+	for _, name := range []string{"enum", "struct", "interface", "mutation", "query"} {
+		assert.NotNil(t, parsed.Lookup(name), name)
+	}
+
+	// Files named after a partial in the TemplateDir override it, while the
+	// templates of other generators sharing the directory are ignored.
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "struct.tmpl"), []byte("{{ define \"struct\" }}\ntype {{ . }} string\n{{ end }}"), 0644))
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "client.go.tmpl"), []byte("{{ define \"struct\" }}custom{{ end }}"), 0644))
+	require.NoError(t, c.WriteFile(&testGenerator{PackageName: "test", Names: []string{"A"}}))
+
+	content, err := ioutil.ReadFile(c.DestinationFile)
+	require.NoError(t, err)
+	assert.Equal(t, "// Code generated by tutone: DO NOT EDIT\npackage test\n\ntype A string\n", string(content))
+
+	parsed, err = c.parseTemplate("types.go.tmpl", templateString)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, parsed.ExecuteTemplate(&buf, "struct", "B"))
+
+	// The output of a partial is marked with its file
+	stripped, lines := stripPartialMarkers(buf.Bytes())
+	assert.Equal(t, "\ntype B string\n", string(stripped))
+	assert.Equal(t, []string{"", path.Join(dir, "struct.tmpl"), ""}, lines)
+}
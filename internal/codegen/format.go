@@ -0,0 +1,157 @@
+package codegen
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"go/scanner"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/tools/imports"
+)
+
+// FailedFileSuffix is appended to the DestinationFile to name the file storing
+// the output of a template that failed to format.
+const FailedFileSuffix = ".tutone-failed"
+
+// FormatError is returned when the output of a template is not valid Go.
+type FormatError struct {
+	// Template is the path of the template that was rendered, or the plugin
+	// that returned the output.
+	Template string
+	// Partial is the path of the partial that rendered the offending line,
+	// empty when it was rendered by the Template itself.
+	Partial string
+	// File is the destination of the output, which is left untouched.
+	File string
+	// FailedFile stores the unformatted output, empty when it wasn't written.
+	FailedFile string
+	// Line and Column are the position of the error in the unformatted output.
+	Line   int
+	Column int
+	// Source is the offending line of the unformatted output.
+	Source string
+	// Type is the name of the type being generated at the offending line.
+	Type string
+	Err  error
+}
+
+func (e *FormatError) Error() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "failed to format %s rendered from %s", e.File, e.Template)
+
+	if e.Partial != "" {
+		fmt.Fprintf(&b, " using partial %s", e.Partial)
+	}
+
+	if e.Line > 0 {
+		fmt.Fprintf(&b, ": line %d, column %d", e.Line, e.Column)
+	}
+
+	if e.Type != "" {
+		fmt.Fprintf(&b, " in type %s", e.Type)
+	}
+
+	fmt.Fprintf(&b, ": %s", e.Err)
+
+	if e.Source != "" {
+		fmt.Fprintf(&b, "\n\t%s", strings.TrimSpace(e.Source))
+	}
+
+	if e.FailedFile != "" {
+		fmt.Fprintf(&b, "\nunformatted output written to %s", e.FailedFile)
+	}
+
+	return b.String()
+}
+
+func (e *FormatError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	typeDeclRegex = regexp.MustCompile(`^type\s+(\w+)`)
+	methodRegex   = regexp.MustCompile(`^func\s+\(\w*\s*\*?(\w+)\)`)
+)
+
+// format returns the formatted output of the template, with the imports fixed.
+// The templatePath may also name another source of the output, such as a
+// plugin.
+// When formatting fails, the unformatted output is written to the failed file
+// and a FormatError is returned.
+func (c *CodeGen) format(templatePath string, content []byte) ([]byte, error) {
+	formatted, err := imports.Process(c.DestinationFile, content, nil)
+	if err == nil {
+		return formatted, nil
+	}
+
+	formatErr := &FormatError{
+		Template: templatePath,
+		File:     c.DestinationFile,
+		Err:      err,
+	}
+
+	var errList scanner.ErrorList
+	if errors.As(err, &errList) && len(errList) > 0 {
+		formatErr.Line = errList[0].Pos.Line
+		formatErr.Column = errList[0].Pos.Column
+		formatErr.Err = errors.New(errList[0].Msg)
+		formatErr.Source, formatErr.Type = sourceAt(content, formatErr.Line)
+	}
+
+	// Nothing is written while checking the generated code.
+	if c.Check == nil {
+		failedFile := c.DestinationFile + FailedFileSuffix
+
+		if writeErr := c.writeFailedFile(failedFile, content); writeErr != nil {
+			log.WithFields(log.Fields{
+				"file": failedFile,
+			}).Error(writeErr)
+		} else {
+			formatErr.FailedFile = failedFile
+		}
+	}
+
+	return nil, formatErr
+}
+
+func (c *CodeGen) writeFailedFile(failedFile string, content []byte) error {
+	if _, err := os.Stat(c.DestinationDir); os.IsNotExist(err) {
+		if err = os.MkdirAll(c.DestinationDir, 0755); err != nil {
+			return err
+		}
+	}
+
+	return ioutil.WriteFile(failedFile, content, 0644)
+}
+
+// sourceAt returns the line of the content, along with the name of the type
+// declared, or the receiver of the method defined, nearest before it.
+func sourceAt(content []byte, line int) (string, string) {
+	var source, typeName string
+
+	s := bufio.NewScanner(bytes.NewReader(content))
+	s.Buffer(nil, len(content)+1)
+
+	for n := 1; s.Scan() && n <= line; n++ {
+		text := s.Text()
+
+		if m := typeDeclRegex.FindStringSubmatch(text); m != nil {
+			typeName = m[1]
+		} else if m := methodRegex.FindStringSubmatch(text); m != nil {
+			typeName = m[1]
+		}
+
+		if n == line {
+			source = text
+		}
+	}
+
+	return source, typeName
+}
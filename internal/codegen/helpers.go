@@ -25,11 +25,9 @@ func RenderStringFromGenerator(s string, g Generator) (string, error) {
 }
 
 // RenderTemplate parses and returns the rendered string of the provided template.
-// The template is also assigned the provided name for reference.
-//
-// TODO: How can we compose a template of embedded templates to help scale?
-//       Templates are stored as map[string]*Template - ("someName": *Template).
-//       https://stackoverflow.com/questions/41176355/go-template-name
+// The template is also assigned the provided name for reference.  Templates
+// rendered by a CodeGen are instead composed with the built-in partials, see
+// parseTemplate.
 func RenderTemplate(templateName string, templateString string, data interface{}) (string, error) {
 	tmpl, err := template.New(templateName).Funcs(util.GetTemplateFuncs()).Parse(templateString)
 	if err != nil {
@@ -0,0 +1,254 @@
+package codegen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultManifestFile is where the manifest is stored when the configuration
+// does not specify a location.
+const DefaultManifestFile = ".tutone.manifest.json"
+
+// ManifestEntry is a single file written by a generator.
+type ManifestEntry struct {
+	Path      string `json:"path"`
+	Generator string `json:"generator,omitempty"`
+	Package   string `json:"package,omitempty"`
+	// Hash is the SHA-256 of the contents of the file when it was written.
+	Hash string `json:"hash"`
+}
+
+// Manifest records every file written by the generators, so that the files
+// that are no longer generated can be found, and so that generated files can
+// be overwritten.
+type Manifest struct {
+	Files []ManifestEntry `json:"files"`
+
+	mu      sync.Mutex
+	written map[string]ManifestEntry
+}
+
+// LoadManifest reads the manifest from the file, returning an empty Manifest
+// when the file does not exist.
+func LoadManifest(file string) (*Manifest, error) {
+	m := &Manifest{}
+
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+
+		return nil, err
+	}
+
+	if err := json.Unmarshal(content, m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Save writes the manifest to the file.
+func (m *Manifest) Save(file string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	content, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, append(content, '\n'), 0644)
+}
+
+// Lookup returns the entry for the file, or nil if it is not in the manifest.
+func (m *Manifest) Lookup(file string) *ManifestEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	file = filepath.Clean(file)
+
+	if e, ok := m.written[file]; ok {
+		return &e
+	}
+
+	for _, e := range m.Files {
+		if e.Path == file {
+			return &e
+		}
+	}
+
+	return nil
+}
+
+// IsUnchanged determines if the file is in the manifest, and has not been
+// modified since it was written.
+func (m *Manifest) IsUnchanged(file string) (bool, error) {
+	e := m.Lookup(file)
+	if e == nil {
+		return false, nil
+	}
+
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return false, err
+	}
+
+	return hashContent(content) == e.Hash, nil
+}
+
+// PackageUnchanged determines if the manifest has files for the package, and
+// none of them have been modified or removed since they were written.
+func (m *Manifest) PackageUnchanged(packageName string) bool {
+	m.mu.Lock()
+	var files []string
+	for _, e := range m.Files {
+		if e.Package == packageName {
+			files = append(files, e.Path)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, file := range files {
+		if unchanged, err := m.IsUnchanged(file); err != nil || !unchanged {
+			return false
+		}
+	}
+
+	return len(files) > 0
+}
+
+// Unwritten returns the files previously written by the generator for the
+// package, which have not been written during the current run.
+func (m *Manifest) Unwritten(generator string, packageName string) []ManifestEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var files []ManifestEntry
+	for _, e := range m.Files {
+		if e.Generator != generator || e.Package != packageName {
+			continue
+		}
+
+		if _, ok := m.written[e.Path]; !ok {
+			files = append(files, e)
+		}
+	}
+
+	return files
+}
+
+// Record adds a file written during the current run.
+func (m *Manifest) Record(file string, generator string, packageName string, content []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.written == nil {
+		m.written = map[string]ManifestEntry{}
+	}
+
+	file = filepath.Clean(file)
+
+	m.written[file] = ManifestEntry{
+		Path:      file,
+		Generator: generator,
+		Package:   packageName,
+		Hash:      hashContent(content),
+	}
+}
+
+// Prune removes the files that are in the manifest, but were not written
+// during the current run.  Only the files of the generated packages, or of
+// packages that are no longer configured, are considered, since the others
+// weren't generated at all.  Orphaned files that have been modified since they
+// were generated are reported, and kept in the manifest, rather than removed.
+// The entries of the manifest are then replaced by the files written.  When the
+// check is not nil, orphaned files are reported as stale instead of removed.
+func (m *Manifest) Prune(generatedPackages []string, configuredPackages []string, check *Check) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	contains := func(names []string, name string) bool {
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	files := make([]ManifestEntry, 0, len(m.Files)+len(m.written))
+	for _, e := range m.written {
+		files = append(files, e)
+	}
+
+	for _, e := range m.Files {
+		if _, ok := m.written[e.Path]; ok {
+			continue
+		}
+
+		if contains(configuredPackages, e.Package) && !contains(generatedPackages, e.Package) {
+			files = append(files, e)
+			continue
+		}
+
+		content, err := ioutil.ReadFile(e.Path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return err
+		}
+
+		if hashContent(content) != e.Hash {
+			log.WithFields(log.Fields{
+				"file":      e.Path,
+				"generator": e.Generator,
+				"package":   e.Package,
+			}).Warn("orphaned file has been modified since it was generated, not removing")
+
+			files = append(files, e)
+			continue
+		}
+
+		if check != nil {
+			check.Stale(e.Path)
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"file":      e.Path,
+			"generator": e.Generator,
+			"package":   e.Package,
+		}).Info("removing orphaned file")
+
+		if err := os.Remove(e.Path); err != nil {
+			return err
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+
+	m.Files = files
+	m.written = nil
+
+	return nil
+}
+
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,106 @@
+//go:build unit
+// +build unit
+
+package codegen
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifest_Prune(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "tutone-manifest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := func(name string, content string) string {
+		p := path.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(p, []byte(content), 0644))
+		return p
+	}
+
+	m, err := LoadManifest(path.Join(dir, "missing.json"))
+	require.NoError(t, err)
+	assert.Empty(t, m.Files)
+
+	kept := file("kept.go", "kept")
+	orphaned := file("orphaned.go", "orphaned")
+	modified := file("modified.go", "modified")
+	removedPackage := file("removed.go", "removed")
+	otherPackage := file("other.go", "other")
+
+	m.Record(kept, "typegen", "alerts", []byte("kept"))
+	m.Record(orphaned, "nerdgraphclient", "alerts", []byte("orphaned"))
+	m.Record(modified, "typegen", "cloud", []byte("original"))
+	m.Record(removedPackage, "typegen", "removed", []byte("removed"))
+	m.Record(otherPackage, "typegen", "other", []byte("other"))
+	require.NoError(t, m.Prune([]string{"alerts", "cloud", "other", "removed"}, []string{"alerts", "cloud", "other", "removed"}, nil))
+
+	manifestFile := path.Join(dir, "manifest.json")
+	require.NoError(t, m.Save(manifestFile))
+
+	m, err = LoadManifest(manifestFile)
+	require.NoError(t, err)
+	assert.Len(t, m.Files, 5)
+	assert.Equal(t, "nerdgraphclient", m.Lookup(orphaned).Generator)
+
+	unchanged, err := m.IsUnchanged(modified)
+	require.NoError(t, err)
+	assert.False(t, unchanged)
+
+	// Only the alerts package is generated, and the removed package is no
+	// longer configured.
+	m.Record(kept, "typegen", "alerts", []byte("kept"))
+	require.NoError(t, m.Prune([]string{"alerts", "cloud"}, []string{"alerts", "cloud", "other"}, nil))
+
+	assert.FileExists(t, kept)
+	assert.NoFileExists(t, orphaned)
+	assert.FileExists(t, modified)
+	assert.NoFileExists(t, removedPackage)
+	assert.FileExists(t, otherPackage)
+
+	var paths []string
+	for _, e := range m.Files {
+		paths = append(paths, e.Path)
+	}
+	assert.Equal(t, []string{kept, modified, otherPackage}, paths)
+}
+
+func TestCodeGen_WriteFile_Overwrite(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "tutone-overwrite")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	m := &Manifest{}
+
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "types.go.tmpl"), []byte("package {{ .PackageName }}\n\ntype A string\n"), 0644))
+
+	c := CodeGen{
+		TemplateDir:     dir,
+		TemplateName:    "types.go.tmpl",
+		DestinationDir:  dir,
+		DestinationFile: path.Join(dir, "types.go"),
+		GeneratorName:   "typegen",
+		PackageName:     "test",
+		Output:          Output{Manifest: m},
+	}
+
+	// Files without the header are only overwritten when they are unchanged
+	// since they were generated.
+	require.NoError(t, c.WriteFile(&testGenerator{PackageName: "test"}))
+	assert.Equal(t, "typegen", m.Lookup(c.DestinationFile).Generator)
+	require.NoError(t, c.WriteFile(&testGenerator{PackageName: "test"}))
+
+	require.NoError(t, ioutil.WriteFile(c.DestinationFile, []byte("package test\n"), 0644))
+	err = c.WriteFile(&testGenerator{PackageName: "test"})
+	assert.EqualError(t, err, "refusing to overwrite "+c.DestinationFile+", which does not start with the \"// Code generated by tutone\" header")
+}
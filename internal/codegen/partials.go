@@ -0,0 +1,103 @@
+package codegen
+
+import (
+	"bytes"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/newrelic/tutone/internal/util"
+)
+
+// The output of each partial is enclosed in markers naming the file it was
+// defined in, which are removed before formatting.  The end marker has an
+// empty name.
+const (
+	partialMarkerStart = '\x1e'
+	partialMarkerEnd   = '\x1f'
+)
+
+// parsePartials adds the templates defined in the file to the template,
+// replacing any already defined with the same name.  Their output is marked
+// with the file, see stripPartialMarkers.
+func parsePartials(tmpl *template.Template, file string, content string) error {
+	partials, err := template.New(file).Funcs(util.GetTemplateFuncs()).Parse(content)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range partials.Templates() {
+		// The file itself only holds the definitions
+		if p.Name() == file || p.Tree == nil {
+			continue
+		}
+
+		markPartial(p.Tree, file)
+
+		if _, err := tmpl.AddParseTree(p.Name(), p.Tree); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// markPartial encloses the output of the template in markers naming the file.
+func markPartial(tree *parse.Tree, file string) {
+	marker := func(name string) parse.Node {
+		text := []byte{partialMarkerStart}
+		text = append(text, name...)
+		text = append(text, partialMarkerEnd)
+
+		return &parse.TextNode{NodeType: parse.NodeText, Pos: tree.Root.Pos, Text: text}
+	}
+
+	nodes := make([]parse.Node, 0, len(tree.Root.Nodes)+2)
+	nodes = append(nodes, marker(file))
+	nodes = append(nodes, tree.Root.Nodes...)
+	nodes = append(nodes, marker(""))
+
+	tree.Root.Nodes = nodes
+}
+
+// stripPartialMarkers returns the output without the markers of the partials,
+// along with the file of the partial that rendered each line, empty for the
+// lines rendered by the template itself.  A line belongs to the partial that
+// rendered its last non-blank character.
+func stripPartialMarkers(content []byte) ([]byte, []string) {
+	stripped := make([]byte, 0, len(content))
+	lines := []string{""}
+
+	var files []string
+
+	for i := 0; i < len(content); i++ {
+		b := content[i]
+
+		if b == partialMarkerStart {
+			if end := bytes.IndexByte(content[i:], partialMarkerEnd); end > 0 {
+				if name := string(content[i+1 : i+end]); name != "" {
+					files = append(files, name)
+				} else if len(files) > 0 {
+					files = files[:len(files)-1]
+				}
+
+				i += end
+				continue
+			}
+		}
+
+		stripped = append(stripped, b)
+
+		switch b {
+		case '\n':
+			lines = append(lines, "")
+		case ' ', '\t', '\r':
+		default:
+			lines[len(lines)-1] = ""
+			if len(files) > 0 {
+				lines[len(lines)-1] = files[len(files)-1]
+			}
+		}
+	}
+
+	return stripped, lines
+}
@@ -2,10 +2,8 @@ package config
 
 import (
 	"errors"
-	"io/ioutil"
 
 	log "github.com/sirupsen/logrus"
-	"gopkg.in/yaml.v2"
 )
 
 // Config is the information keeper for generating go structs from type names.
@@ -22,10 +20,26 @@ type Config struct {
 	Packages []PackageConfig `yaml:"packages,omitempty"`
 	// Generators configure the work engine of this project.
 	Generators []GeneratorConfig `yaml:"generators,omitempty"`
+	// Scalars map GraphQL scalar types to Go types for all packages.
+	Scalars []ScalarConfig `yaml:"scalars,omitempty"`
+	// Manifest is the file that records every generated file, used to remove
+	// the files that are no longer generated.
+	Manifest string `yaml:"manifest,omitempty"`
+	// Include is a list of glob patterns of files, relative to this file, whose
+	// packages, generators and scalars are added to the configuration.
+	Include []string `yaml:"include,omitempty"`
+	// Extends is a base configuration file, relative to this file, used for the
+	// fields this file doesn't set.  Packages, generators and scalars are
+	// merged by name.
+	Extends string `yaml:"extends,omitempty"`
+	// PackageDefaults are used for the fields that a package doesn't set.
+	PackageDefaults PackageConfig `yaml:"package_defaults,omitempty"`
 }
 
 // AuthConfig is the information necessary to authenticate to the NerdGraph API.
 type AuthConfig struct {
+	// Disable sending the API key when fetching the schema.
+	Disable bool `yaml:"disable,omitempty"`
 	// Header is the name of the API request header that is used to authenticate.
 	Header string `yaml:"header,omitempty"`
 	// EnvVar is the name of the environment variable to attach to the above header.
@@ -56,10 +70,33 @@ type PackageConfig struct {
 	Generators []string `yaml:"generators,omitempty"`
 	// Imports is a list of strings to represent what pacakges to import for a given package.
 	Imports []string `yaml:"imports,omitempty"`
-
+	// Commands is a list of CLI commands generated by the command generator.
 	Commands []Command `yaml:"commands,omitempty"`
-
+	// Resources is a list of Terraform resources generated by the terraform
+	// generator.
+	Resources []Resource `yaml:"resources,omitempty"`
+	// Queries is a list of query endpoints to generate methods for.
 	Queries []Query `yaml:"queries,omitempty"`
+	// SelectionBuilders enables the generation of typed selection set builders
+	// for the object types in the package, allowing callers to choose which
+	// fields to request at runtime.
+	SelectionBuilders bool `yaml:"selection_builders,omitempty"`
+	// QueryFragments enables the use of named GraphQL fragments for the nested
+	// selections in the generated query strings, which are shared across all of
+	// the operations in the package.
+	QueryFragments bool `yaml:"query_fragments,omitempty"`
+	// StrictEnums enables the generation of an UnmarshalJSON method for each
+	// enum type, which rejects any value not defined in the schema.
+	StrictEnums bool `yaml:"strict_enums,omitempty"`
+	// ValidateInputs enables the generation of a Validate method for each input
+	// object, which is called by the mutation methods before the request is sent.
+	ValidateInputs bool `yaml:"validate_inputs,omitempty"`
+	// ExplicitNulls adds a NullFields field to each input object with nullable
+	// fields, listing the fields which are sent as null to clear their value.
+	ExplicitNulls bool `yaml:"explicit_nulls,omitempty"`
+	// Scalars map GraphQL scalar types to Go types for the package, taking
+	// precedence over the global Scalars.
+	Scalars []ScalarConfig `yaml:"scalars,omitempty"`
 }
 
 // Query is the information necessary to build a query method.  The Paths
@@ -72,27 +109,79 @@ type Query struct {
 	Endpoints []EndpointConfig `yaml:"endpoints,omitempty"`
 }
 
+// Command is the information necessary to generate a CLI command, whose
+// subcommands call the methods of the generated client.
 type Command struct {
-	Name              string        `yaml:"name,omitempty"`
-	FileName          string        `yaml:"fileName,omitempty"`
-	ShortDescription  string        `yaml:"shortDescription,omitempty"`
-	LongDescription   string        `yaml:"longDescription,omitempty"`
-	Example           string        `yaml:"example,omitempty"`
-	InputType         string        `yaml:"inputType,omitempty"`
-	ClientPackageName string        `yaml:"clientPackageName,omitempty"`
-	ClientMethod      string        `yaml:"clientMethod,omitempty"`
-	Flags             []CommandFlag `yaml:"flags,omitempty"`
-	Subcommands       []Command     `yaml:"subcommands,omitempty"`
-	GraphQLPath       []string      `yaml:"path,omitempty"`
+	// Name of the command, or of the mutation called by a subcommand.
+	Name string `yaml:"name,omitempty"`
+	// FileName is the file the command is generated in, named after the
+	// command by default.
+	FileName string `yaml:"fileName,omitempty"`
+	// ShortDescription is shown in the help of the command.
+	ShortDescription string `yaml:"shortDescription,omitempty"`
+	// LongDescription is shown in the detailed help of the command.
+	LongDescription string `yaml:"longDescription,omitempty"`
+	// Example is shown in the help of the command.
+	Example string `yaml:"example,omitempty"`
+	// InputType is the name of the GraphQL input type of the command.
+	InputType string `yaml:"inputType,omitempty"`
+	// ClientPackageName is the name of the package of the generated client,
+	// used to qualify the client types.
+	ClientPackageName string `yaml:"clientPackageName,omitempty"`
+	// ClientMethod is the name of the client method called by the command.
+	ClientMethod string `yaml:"clientMethod,omitempty"`
+	// Flags is a list of the flags of the command.
+	Flags []CommandFlag `yaml:"flags,omitempty"`
+	// Subcommands is a list of the commands nested under this command.
+	Subcommands []Command `yaml:"subcommands,omitempty"`
+	// GraphQLPath is the path of field names to the query endpoint called by
+	// the command.
+	GraphQLPath []string `yaml:"path,omitempty"`
 }
 
+// CommandFlag is the information about a single flag of a command.
 type CommandFlag struct {
-	Name         string `yaml:"name,omitempty"`
-	Type         string `yaml:"type,omitempty"`
+	// Name of the flag.
+	Name string `yaml:"name,omitempty"`
+	// Type is the Go type of the flag value.
+	Type string `yaml:"type,omitempty"`
+	// DefaultValue of the flag when it isn't set.
 	DefaultValue string `yaml:"defaultValue"`
-	Description  string `yaml:"description"`
+	// Description is shown in the help of the command.
+	Description string `yaml:"description"`
+	// VariableName is the name of the variable the flag value is stored in.
 	VariableName string `yaml:"variableName"`
-	Required     bool   `yaml:"required"`
+	// Required flags must be set to run the command.
+	Required bool `yaml:"required"`
+}
+
+// Resource is the information necessary to generate a Terraform resource,
+// whose attributes are the fields of an input object, and whose CRUD functions
+// call the client methods of the configured mutations and queries.
+type Resource struct {
+	// Name of the resource type, i.e. newrelic_alert_policy
+	Name string `yaml:"name"`
+	// InputType is the name of the GraphQL input object of the attributes.
+	InputType string `yaml:"inputType"`
+	// ClientPackageName is the name of the package of the generated client,
+	// used to qualify the client types.
+	ClientPackageName string `yaml:"clientPackageName,omitempty"`
+	// Client is the Go expression of the client whose methods are called,
+	// given the meta of the provider.  Defaults to
+	// meta.(*ProviderConfig).NewClient.<ClientPackageName>
+	Client string `yaml:"client,omitempty"`
+	// Create is the name of the mutation creating the resource.
+	Create string `yaml:"create,omitempty"`
+	// Read is the name of the query endpoint reading the resource.
+	Read string `yaml:"read,omitempty"`
+	// Update is the name of the mutation updating the resource.  Every
+	// attribute forces a new resource when there is no update.
+	Update string `yaml:"update,omitempty"`
+	// Delete is the name of the mutation deleting the resource.
+	Delete string `yaml:"delete,omitempty"`
+	// IDField is the field of the result of the create holding the ID of the
+	// resource.  Defaults to id.
+	IDField string `yaml:"idField,omitempty"`
 }
 
 // GeneratorConfig is the information necessary to execute a generator.
@@ -107,22 +196,53 @@ type GeneratorConfig struct {
 	TemplateName string `yaml:"templateName,omitempty"`
 	// TemplateURL is a URL to a downloadable file to use as a Go template
 	TemplateURL string `yaml:"templateURL,omitempty"`
+	// Split is the strategy used to split the generated code into several files
+	// named after the FileName, one of "kind", "prefix" or "operation".
+	Split string `yaml:"split,omitempty"`
+	// Plugin is the path to an executable that generates the files, used in
+	// place of a built-in generator.  See the pkg/plugin package.
+	Plugin string `yaml:"plugin,omitempty"`
+}
+
+// ScalarConfig is the information about the Go type used for a GraphQL scalar.
+type ScalarConfig struct {
+	// Name of the GraphQL scalar type.
+	Name string `yaml:"name"`
+	// Type is the Go type for the scalar, qualified by the package name when
+	// necessary, i.e. time.Time
+	Type string `yaml:"type"`
+	// Imports is a list of the packages required by the Type, Marshaler and Unmarshaler.
+	Imports []string `yaml:"imports,omitempty"`
+	// Marshaler is the name of a func(Type) ([]byte, error) used to encode the scalar as JSON.
+	Marshaler string `yaml:"marshaler,omitempty"`
+	// Unmarshaler is the name of a func([]byte) (Type, error) used to decode the scalar from JSON.
+	Unmarshaler string `yaml:"unmarshaler,omitempty"`
 }
 
 // MutationConfig is the information about the GraphQL mutations.
 type MutationConfig struct {
-	// Name is the name of the GraphQL method.
-	Name                  string            `yaml:"name"`
-	MaxQueryFieldDepth    int               `yaml:"max_query_field_depth,omitempty"`
+	// Name is the name of the GraphQL method, or a pattern matching several.
+	Name string `yaml:"name"`
+	// MaxQueryFieldDepth is the depth of the fields selected from the result.
+	MaxQueryFieldDepth int `yaml:"max_query_field_depth,omitempty"`
+	// ArgumentTypeOverrides maps argument names to the GraphQL type used in
+	// place of the type from the schema, i.e. accountId: "Int!"
 	ArgumentTypeOverrides map[string]string `yaml:"argument_type_overrides,omitempty"`
-	ExcludeFields         []string          `yaml:"exclude_fields,omitempty"`
+	// ExcludeFields is a list of field names left out of the selected fields.
+	ExcludeFields []string `yaml:"exclude_fields,omitempty"`
 }
 
+// EndpointConfig is the information about a single query endpoint.
 type EndpointConfig struct {
-	Name               string   `yaml:"name,omitempty"`
-	MaxQueryFieldDepth int      `yaml:"max_query_field_depth,omitempty"`
-	IncludeArguments   []string `yaml:"include_arguments,omitempty"`
-	ExcludeFields      []string `yaml:"exclude_fields,omitempty"`
+	// Name of the field of the endpoint at the end of the query path.
+	Name string `yaml:"name,omitempty"`
+	// MaxQueryFieldDepth is the depth of the fields selected from the result.
+	MaxQueryFieldDepth int `yaml:"max_query_field_depth,omitempty"`
+	// IncludeArguments is a list of the optional arguments of the endpoint
+	// that are added to the query, along with the required arguments.
+	IncludeArguments []string `yaml:"include_arguments,omitempty"`
+	// ExcludeFields is a list of field names left out of the selected fields.
+	ExcludeFields []string `yaml:"exclude_fields,omitempty"`
 }
 
 // TypeConfig is the information about which types to render and any data specific to handling of the type.
@@ -155,28 +275,20 @@ const (
 	DefaultAuthEnvVar      = "TUTONE_API_KEY"
 )
 
-// LoadConfig will load a config file at the specified path or error.
+// LoadConfig will load a config file at the specified path or error, see
+// Loader.
 func LoadConfig(file string) (*Config, error) {
 	if file == "" {
 		return nil, errors.New("config file name required")
 	}
-	log.WithFields(log.Fields{
-		"file": file,
-	}).Debug("loading package definition")
-
-	yamlFile, err := ioutil.ReadFile(file)
-	if err != nil {
-		return nil, err
-	}
 
-	var config Config
-	err = yaml.Unmarshal(yamlFile, &config)
+	config, err := (&Loader{}).Load(file)
 	if err != nil {
 		return nil, err
 	}
 	log.Tracef("definition: %+v", config)
 
-	return &config, nil
+	return config, nil
 }
 
 func (c *PackageConfig) GetDestinationPath() string {
@@ -187,6 +299,18 @@ func (c *PackageConfig) GetDestinationPath() string {
 	return "./"
 }
 
+// GetScalarConfigByName returns the first scalar mapping for the named GraphQL
+// scalar type, or nil when the scalar is not mapped.
+func (c *PackageConfig) GetScalarConfigByName(name string) *ScalarConfig {
+	for _, scalarConfig := range c.Scalars {
+		if scalarConfig.Name == name {
+			return &scalarConfig
+		}
+	}
+
+	return nil
+}
+
 func (c *PackageConfig) GetTypeConfigByName(name string) *TypeConfig {
 	for _, typeConfig := range c.Types {
 		if typeConfig.Name == name {
@@ -96,6 +96,19 @@ func TestLoadConfig(t *testing.T) {
 				// TemplateName:
 			},
 		},
+		Scalars: []ScalarConfig{
+			{
+				Name: "ID",
+				Type: "string",
+			},
+			{
+				Name:        "EpochMilliseconds",
+				Type:        "time.Time",
+				Imports:     []string{"time", "github.com/newrelic/newrelic-client-go/pkg/nrtime"},
+				Marshaler:   "nrtime.MarshalEpochMilliseconds",
+				Unmarshaler: "nrtime.UnmarshalEpochMilliseconds",
+			},
+		},
 	}
 
 	assert.Equal(t, config, expected)
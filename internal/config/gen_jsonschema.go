@@ -0,0 +1,25 @@
+//go:build ignore
+// +build ignore
+
+// gen_jsonschema writes the JSON Schema of the configuration, run by go
+// generate in this directory.
+package main
+
+import (
+	"io/ioutil"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/newrelic/tutone/internal/config"
+)
+
+func main() {
+	content, err := config.GenerateJSONSchema(".")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(config.JSONSchemaFile, content, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,223 @@
+package config
+
+import (
+	// Embed the generated JSON Schema
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"strings"
+)
+
+//go:generate go run gen_jsonschema.go
+
+// JSONSchemaFile is the file containing the JSON Schema of the configuration,
+// generated from the Config by GenerateJSONSchema.
+const JSONSchemaFile = "tutone.schema.json"
+
+// JSONSchema describes the configuration file, allowing editors to complete
+// and validate it.
+//
+//go:embed tutone.schema.json
+var JSONSchema []byte
+
+// jsonSchema is the subset of JSON Schema used to describe the configuration.
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	AdditionalProperties interface{}            `json:"additionalProperties,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	Definitions          map[string]*jsonSchema `json:"definitions,omitempty"`
+}
+
+// GenerateJSONSchema returns the JSON Schema of the Config, described by the
+// doc comments of the types and fields in the Go source of this package,
+// found in the sourceDir.
+func GenerateJSONSchema(sourceDir string) ([]byte, error) {
+	docs, err := parseDocs(sourceDir)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &schemaGenerator{
+		docs:        docs,
+		definitions: map[string]*jsonSchema{},
+	}
+
+	root := g.object(reflect.TypeOf(Config{}))
+	root.Schema = "http://json-schema.org/draft-07/schema#"
+	root.Title = "tutone configuration"
+	root.Definitions = g.definitions
+
+	content, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(content, '\n'), nil
+}
+
+// typeDocs are the doc comments of a struct type and its fields.
+type typeDocs struct {
+	doc    string
+	fields map[string]string
+}
+
+// parseDocs reads the doc comments of the struct types in the Go source.
+func parseDocs(sourceDir string) (map[string]typeDocs, error) {
+	fset := token.NewFileSet()
+
+	notTest := func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}
+
+	pkgs, err := parser.ParseDir(fset, sourceDir, notTest, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg, ok := pkgs["config"]
+	if !ok {
+		return nil, fmt.Errorf("config package not found in %s", sourceDir)
+	}
+
+	docs := map[string]typeDocs{}
+
+	for _, file := range pkg.Files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+
+				structType, ok := typeSpec.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+
+				// The doc comment of a single type belongs to the declaration.
+				doc := typeSpec.Doc
+				if doc == nil && len(genDecl.Specs) == 1 {
+					doc = genDecl.Doc
+				}
+
+				td := typeDocs{
+					doc:    commentText(doc),
+					fields: map[string]string{},
+				}
+
+				for _, field := range structType.Fields.List {
+					text := commentText(field.Doc)
+					if text == "" {
+						text = commentText(field.Comment)
+					}
+
+					for _, name := range field.Names {
+						td.fields[name.Name] = text
+					}
+				}
+
+				docs[typeSpec.Name.Name] = td
+			}
+		}
+	}
+
+	return docs, nil
+}
+
+// commentText returns the comment as a single line.
+func commentText(c *ast.CommentGroup) string {
+	if c == nil {
+		return ""
+	}
+
+	return strings.Join(strings.Fields(c.Text()), " ")
+}
+
+type schemaGenerator struct {
+	docs        map[string]typeDocs
+	definitions map[string]*jsonSchema
+}
+
+// object describes a struct type, with a property for each field decoded from
+// the YAML.
+func (g *schemaGenerator) object(t reflect.Type) *jsonSchema {
+	s := &jsonSchema{
+		Description:          g.docs[t.Name()].doc,
+		Type:                 "object",
+		Properties:           map[string]*jsonSchema{},
+		AdditionalProperties: false,
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name := yamlName(f)
+		if name == "-" {
+			continue
+		}
+
+		property := g.schemaFor(f.Type)
+		property.Description = g.docs[t.Name()].fields[f.Name]
+
+		s.Properties[name] = property
+	}
+
+	return s
+}
+
+// schemaFor describes a type, with struct types added to the definitions.
+func (g *schemaGenerator) schemaFor(t reflect.Type) *jsonSchema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schemaFor(t.Elem())
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &jsonSchema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &jsonSchema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if _, ok := g.definitions[t.Name()]; !ok {
+			// Added before the fields, since types such as Command contain themselves.
+			g.definitions[t.Name()] = &jsonSchema{}
+			*g.definitions[t.Name()] = *g.object(t)
+		}
+
+		return &jsonSchema{Ref: "#/definitions/" + t.Name()}
+	default:
+		return &jsonSchema{}
+	}
+}
+
+// yamlName returns the key of the field in the YAML, which defaults to the
+// lower case name of the field.
+func yamlName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+	if name == "" {
+		return strings.ToLower(f.Name)
+	}
+
+	return name
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestJSONSchema ensures the published JSON Schema matches the Config.
+func TestJSONSchema(t *testing.T) {
+	t.Parallel()
+
+	content, err := GenerateJSONSchema(".")
+	require.NoError(t, err)
+	assert.Equal(t, string(content), string(JSONSchema), "the JSON Schema is out of date, run go generate ./internal/config")
+
+	var s jsonSchema
+	require.NoError(t, json.Unmarshal(content, &s))
+
+	assert.Equal(t, "#/definitions/PackageConfig", s.Properties["packages"].Items.Ref)
+	assert.Equal(t, "#/definitions/GeneratorConfig", s.Properties["generators"].Items.Ref)
+	assert.Equal(t, false, s.AdditionalProperties)
+
+	pkg := s.Definitions["PackageConfig"]
+	require.NotNil(t, pkg)
+	assert.Equal(t, "#/definitions/Command", pkg.Properties["commands"].Items.Ref)
+	assert.Equal(t, "#/definitions/TypeConfig", pkg.Properties["types"].Items.Ref)
+	assert.Equal(t, "Name is the string that is used to refer to the name of the package.", pkg.Properties["name"].Description)
+
+	// Command contains itself
+	assert.Equal(t, "#/definitions/Command", s.Definitions["Command"].Properties["subcommands"].Items.Ref)
+
+	// Fields without a yaml name use the lower case field name
+	assert.Equal(t, "boolean", s.Definitions["CacheConfig"].Properties["enable"].Type)
+}
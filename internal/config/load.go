@@ -0,0 +1,351 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// Loader reads a configuration file, along with the files it includes and
+// extends.
+type Loader struct {
+	// Files are the configuration files read, starting with the file given to
+	// Load.
+	Files []string
+
+	loading map[string]bool
+	// packages and generators record the file each name was first read from.
+	packages   map[string]string
+	generators map[string]string
+	// packageKeys are the keys set by each package in the file it was first
+	// read from, which take precedence over the PackageDefaults even when
+	// they are false or empty.
+	packageKeys map[string]map[string]bool
+}
+
+// Load reads the configuration file.  The packages, generators and scalars of
+// the files matching its Include patterns are added, the fields it doesn't set
+// are taken from the file it Extends, and the PackageDefaults are used for the
+// fields each package doesn't set, so that a package can turn off a default
+// with an explicit false.  ${NAME} in any string field is replaced by the
+// environment variable, or by the default in ${NAME:-default} when the variable
+// is unset or empty, while $${ is a literal ${.
+func (l *Loader) Load(file string) (*Config, error) {
+	l.Files = nil
+	l.loading = map[string]bool{}
+	l.packages = map[string]string{}
+	l.generators = map[string]string{}
+	l.packageKeys = map[string]map[string]bool{}
+
+	config, err := l.load(file, false)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range config.Packages {
+		mergePackageDefaults(&config.Packages[i], config.PackageDefaults, l.packageKeys[config.Packages[i].Name])
+	}
+
+	return config, nil
+}
+
+func (l *Loader) load(file string, included bool) (*Config, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.loading[abs] {
+		return nil, fmt.Errorf("%s: included or extended by itself", file)
+	}
+
+	l.loading[abs] = true
+	defer delete(l.loading, abs)
+
+	log.WithFields(log.Fields{
+		"file": file,
+	}).Debug("loading package definition")
+
+	yamlFile, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err = yaml.Unmarshal(yamlFile, &config); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	if err = interpolate(reflect.ValueOf(&config).Elem()); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	// The keys of a package can't be told apart from the zero values once
+	// decoded.
+	var keys struct {
+		Packages []map[string]interface{} `yaml:"packages"`
+	}
+
+	if err = yaml.Unmarshal(yamlFile, &keys); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	for i, p := range keys.Packages {
+		name := config.Packages[i].Name
+		if _, ok := l.packageKeys[name]; ok {
+			continue
+		}
+
+		l.packageKeys[name] = map[string]bool{}
+		for key := range p {
+			l.packageKeys[name][key] = true
+		}
+	}
+
+	l.Files = append(l.Files, file)
+
+	if included {
+		rest := config
+		rest.Packages, rest.Generators, rest.Scalars, rest.Include = nil, nil, nil, nil
+
+		if !reflect.DeepEqual(rest, Config{}) {
+			return nil, fmt.Errorf("%s: only packages, generators, scalars and include can be set in an included file", file)
+		}
+	}
+
+	if err = l.record(file, &config); err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(file)
+
+	for _, pattern := range config.Include {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid include %s: %w", file, pattern, err)
+		}
+
+		if len(matches) == 0 {
+			log.WithFields(log.Fields{
+				"file":    file,
+				"include": pattern,
+			}).Warn("no files match the include")
+		}
+
+		for _, match := range matches {
+			inc, err := l.load(match, true)
+			if err != nil {
+				return nil, err
+			}
+
+			config.Packages = append(config.Packages, inc.Packages...)
+			config.Generators = append(config.Generators, inc.Generators...)
+			config.Scalars = append(config.Scalars, inc.Scalars...)
+		}
+	}
+
+	config.Include = nil
+
+	if config.Extends == "" {
+		return &config, nil
+	}
+
+	// Names in a base file may be configured again, to replace them.
+	packages, generators := l.packages, l.generators
+	l.packages, l.generators = map[string]string{}, map[string]string{}
+
+	base, err := l.load(filepath.Join(dir, config.Extends), false)
+	if err != nil {
+		return nil, err
+	}
+
+	l.packages, l.generators = packages, generators
+
+	return mergeConfig(base, &config), nil
+}
+
+// record adds the names of the packages and generators of the file, returning
+// an error when another file has already configured them.
+func (l *Loader) record(file string, config *Config) error {
+	for _, p := range config.Packages {
+		if other, ok := l.packages[p.Name]; ok && other != file {
+			return fmt.Errorf("%s: package %s is already configured in %s", file, p.Name, other)
+		}
+
+		l.packages[p.Name] = file
+	}
+
+	for _, g := range config.Generators {
+		if other, ok := l.generators[g.Name]; ok && other != file {
+			return fmt.Errorf("%s: generator %s is already configured in %s", file, g.Name, other)
+		}
+
+		l.generators[g.Name] = file
+	}
+
+	return nil
+}
+
+// mergeConfig returns the config with the fields it doesn't set taken from the
+// base.  The packages, generators and scalars of both are kept, with those of
+// the config replacing any of the base with the same name.
+func mergeConfig(base *Config, config *Config) *Config {
+	merged := *config
+	mergeDefaults(reflect.ValueOf(&merged).Elem(), reflect.ValueOf(*base))
+
+	merged.Packages = mergeNamed(reflect.ValueOf(base.Packages), reflect.ValueOf(config.Packages)).Interface().([]PackageConfig)
+	merged.Generators = mergeNamed(reflect.ValueOf(base.Generators), reflect.ValueOf(config.Generators)).Interface().([]GeneratorConfig)
+	merged.Scalars = mergeNamed(reflect.ValueOf(base.Scalars), reflect.ValueOf(config.Scalars)).Interface().([]ScalarConfig)
+	merged.Extends = ""
+
+	return &merged
+}
+
+// mergeDefaults sets the zero fields of the struct to the defaults, merging
+// nested structs field by field.
+func mergeDefaults(v reflect.Value, defaults reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+
+		if field.Kind() == reflect.Struct {
+			mergeDefaults(field, defaults.Field(i))
+			continue
+		}
+
+		if field.IsZero() {
+			field.Set(defaults.Field(i))
+		}
+	}
+}
+
+// mergePackageDefaults sets the fields of the package to the defaults, unless
+// their key is set by the package.
+func mergePackageDefaults(pkgConfig *PackageConfig, defaults PackageConfig, keys map[string]bool) {
+	v := reflect.ValueOf(pkgConfig).Elem()
+
+	for i := 0; i < v.NumField(); i++ {
+		key := strings.Split(v.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		if keys[key] || !v.Field(i).IsZero() {
+			continue
+		}
+
+		v.Field(i).Set(reflect.ValueOf(defaults).Field(i))
+	}
+}
+
+// mergeNamed returns the elements of the base, replaced by the elements of the
+// overrides with the same Name, followed by the remaining overrides.
+func mergeNamed(base reflect.Value, overrides reflect.Value) reflect.Value {
+	if base.Len() == 0 {
+		return overrides
+	}
+
+	merged := reflect.MakeSlice(base.Type(), 0, base.Len()+overrides.Len())
+	replaced := map[int]bool{}
+
+	for i := 0; i < base.Len(); i++ {
+		element := base.Index(i)
+
+		for j := 0; j < overrides.Len(); j++ {
+			if overrides.Index(j).FieldByName("Name").String() == element.FieldByName("Name").String() {
+				element = overrides.Index(j)
+				replaced[j] = true
+				break
+			}
+		}
+
+		merged = reflect.Append(merged, element)
+	}
+
+	for j := 0; j < overrides.Len(); j++ {
+		if !replaced[j] {
+			merged = reflect.Append(merged, overrides.Index(j))
+		}
+	}
+
+	return merged
+}
+
+// envVariable matches ${NAME}, ${NAME:-default} and the escaped $${.
+var envVariable = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// expandEnv replaces the environment variables in the string.
+func expandEnv(s string) (string, error) {
+	var err error
+
+	expanded := envVariable.ReplaceAllStringFunc(s, func(m string) string {
+		if m == "$${" {
+			return "${"
+		}
+
+		match := envVariable.FindStringSubmatch(m)
+
+		value := os.Getenv(match[1])
+		if value != "" {
+			return value
+		}
+
+		if _, set := os.LookupEnv(match[1]); !set && !strings.Contains(m, ":-") && err == nil {
+			err = fmt.Errorf("environment variable %s is not set", match[1])
+		}
+
+		return match[2]
+	})
+
+	return expanded, err
+}
+
+// interpolate replaces the environment variables in every string of the value.
+func interpolate(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.String:
+		if !v.CanSet() {
+			return nil
+		}
+
+		expanded, err := expandEnv(v.String())
+		if err != nil {
+			return err
+		}
+
+		v.SetString(expanded)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if err := interpolate(v.Field(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := interpolate(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+
+		iter := v.MapRange()
+		for iter.Next() {
+			expanded, err := expandEnv(iter.Value().String())
+			if err != nil {
+				return err
+			}
+
+			v.SetMapIndex(iter.Key(), reflect.ValueOf(expanded).Convert(v.Type().Elem()))
+		}
+	}
+
+	return nil
+}
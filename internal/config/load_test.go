@@ -0,0 +1,217 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFiles writes the files, named relative to a temporary directory, and
+// returns the directory.
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "tutone-config")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	for name, content := range files {
+		file := path.Join(dir, name)
+		require.NoError(t, os.MkdirAll(path.Dir(file), 0755))
+		require.NoError(t, ioutil.WriteFile(file, []byte(content), 0644))
+	}
+
+	return dir
+}
+
+func TestLoader_Include(t *testing.T) {
+	t.Parallel()
+
+	dir := writeFiles(t, map[string]string{
+		".tutone.yml": `
+include:
+  - pkg/*/.tutone.yml
+generators:
+  - name: typegen
+package_defaults:
+  generators: [typegen]
+packages:
+  - name: nerdgraph
+`,
+		"pkg/alerts/.tutone.yml": `
+packages:
+  - name: alerts
+    path: pkg/alerts
+`,
+		"pkg/apm/.tutone.yml": `
+packages:
+  - name: apm
+    generators: [command]
+generators:
+  - name: command
+`,
+	})
+
+	loader := &Loader{}
+	cfg, err := loader.Load(path.Join(dir, ".tutone.yml"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		path.Join(dir, ".tutone.yml"),
+		path.Join(dir, "pkg/alerts/.tutone.yml"),
+		path.Join(dir, "pkg/apm/.tutone.yml"),
+	}, loader.Files)
+
+	assert.Equal(t, []PackageConfig{
+		{Name: "nerdgraph", Generators: []string{"typegen"}},
+		{Name: "alerts", Path: "pkg/alerts", Generators: []string{"typegen"}},
+		{Name: "apm", Generators: []string{"command"}},
+	}, cfg.Packages)
+	assert.Equal(t, []GeneratorConfig{{Name: "typegen"}, {Name: "command"}}, cfg.Generators)
+	assert.Nil(t, cfg.Include)
+}
+
+func TestLoader_IncludeErrors(t *testing.T) {
+	t.Parallel()
+
+	dir := writeFiles(t, map[string]string{
+		".tutone.yml": `
+include: [alerts.yml]
+packages:
+  - name: alerts
+`,
+		"alerts.yml": `
+packages:
+  - name: alerts
+`,
+		"cycle.yml": `
+include: [cycle.yml]
+`,
+		"endpoint.yml": `
+include: [sub/endpoint.yml]
+`,
+		"sub/endpoint.yml": `
+endpoint: https://example.com
+`,
+	})
+
+	_, err := LoadConfig(path.Join(dir, ".tutone.yml"))
+	assert.EqualError(t, err, path.Join(dir, "alerts.yml")+": package alerts is already configured in "+path.Join(dir, ".tutone.yml"))
+
+	_, err = LoadConfig(path.Join(dir, "cycle.yml"))
+	assert.EqualError(t, err, path.Join(dir, "cycle.yml")+": included or extended by itself")
+
+	_, err = LoadConfig(path.Join(dir, "endpoint.yml"))
+	assert.EqualError(t, err, path.Join(dir, "sub/endpoint.yml")+": only packages, generators, scalars and include can be set in an included file")
+}
+
+func TestLoader_PackageDefaults(t *testing.T) {
+	t.Parallel()
+
+	dir := writeFiles(t, map[string]string{
+		"base.yml": `
+packages:
+  - name: apm
+    strict_enums: false
+`,
+		".tutone.yml": `
+extends: base.yml
+package_defaults:
+  generators: [typegen]
+  validate_inputs: true
+  strict_enums: true
+packages:
+  - name: alerts
+  - name: nerdgraph
+    validate_inputs: false
+    generators: []
+`,
+	})
+
+	cfg, err := LoadConfig(path.Join(dir, ".tutone.yml"))
+	require.NoError(t, err)
+
+	// An explicit false or empty list is not replaced by the defaults
+	assert.Equal(t, []PackageConfig{
+		{Name: "apm", Generators: []string{"typegen"}, ValidateInputs: true},
+		{Name: "alerts", Generators: []string{"typegen"}, ValidateInputs: true, StrictEnums: true},
+		{Name: "nerdgraph", Generators: []string{}, StrictEnums: true},
+	}, cfg.Packages)
+}
+
+func TestLoader_Extends(t *testing.T) {
+	t.Parallel()
+
+	dir := writeFiles(t, map[string]string{
+		"base.yml": `
+endpoint: https://api.newrelic.com/graphql
+auth:
+  header: Api-Key
+  api_key_env_var: NEW_RELIC_API_KEY
+generators:
+  - name: typegen
+    fileName: types.go
+packages:
+  - name: alerts
+    path: pkg/alerts
+  - name: apm
+`,
+		".tutone.yml": `
+extends: base.yml
+auth:
+  api_key_env_var: NEW_RELIC_ADMIN_KEY
+packages:
+  - name: alerts
+    path: pkg/alerting
+  - name: nerdgraph
+`,
+	})
+
+	cfg, err := LoadConfig(path.Join(dir, ".tutone.yml"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://api.newrelic.com/graphql", cfg.Endpoint)
+	assert.Equal(t, AuthConfig{Header: "Api-Key", EnvVar: "NEW_RELIC_ADMIN_KEY"}, cfg.Auth)
+	assert.Equal(t, []GeneratorConfig{{Name: "typegen", FileName: "types.go"}}, cfg.Generators)
+	assert.Equal(t, []PackageConfig{
+		{Name: "alerts", Path: "pkg/alerting"},
+		{Name: "apm"},
+		{Name: "nerdgraph"},
+	}, cfg.Packages)
+	assert.Empty(t, cfg.Extends)
+}
+
+func TestLoader_Env(t *testing.T) {
+	require.NoError(t, os.Setenv("TUTONE_TEST_ENDPOINT", "https://example.com/graphql"))
+	defer os.Unsetenv("TUTONE_TEST_ENDPOINT")
+
+	dir := writeFiles(t, map[string]string{
+		".tutone.yml": `
+endpoint: ${TUTONE_TEST_ENDPOINT}
+auth:
+  header: ${TUTONE_TEST_HEADER:-Api-Key}
+packages:
+  - name: alerts
+    path: pkg/${TUTONE_TEST_ENDPOINT_PATH:-alerts}
+    imports:
+      - $${NOT_INTERPOLATED}
+`,
+		"unset.yml": `
+packages:
+  - name: ${TUTONE_TEST_UNSET}
+`,
+	})
+
+	cfg, err := LoadConfig(path.Join(dir, ".tutone.yml"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://example.com/graphql", cfg.Endpoint)
+	assert.Equal(t, "Api-Key", cfg.Auth.Header)
+	assert.Equal(t, "pkg/alerts", cfg.Packages[0].Path)
+	assert.Equal(t, []string{"${NOT_INTERPOLATED}"}, cfg.Packages[0].Imports)
+
+	_, err = LoadConfig(path.Join(dir, "unset.yml"))
+	assert.EqualError(t, err, path.Join(dir, "unset.yml")+": environment variable TUTONE_TEST_UNSET is not set")
+}
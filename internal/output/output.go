@@ -4,12 +4,24 @@ import (
 	"fmt"
 )
 
+var quiet bool
+
+// SetQuiet disables the success messages, such as when a summary is printed
+// instead.
+func SetQuiet(q bool) {
+	quiet = q
+}
+
 // PrintSuccessMessage prints a message to the console informing
 // the user that code generation was a success and outputs the
 // package and file path for reference.
 //
 // Emoji unicode reference: http://www.unicode.org/emoji/charts/emoji-list.html
 func PrintSuccessMessage(packagePath string, filePath string) {
+	if quiet {
+		return
+	}
+
 	// Emoji = \u2705
 	fmt.Print("\n\u2705 Code generation complete: \n\n")
 	fmt.Printf("   Package:   %v \n", packagePath)
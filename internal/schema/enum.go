@@ -22,6 +22,22 @@ func (e *EnumValue) GetDescription() string {
 	return formatDescription("", e.Description)
 }
 
+// GetDeprecationReason returns the reason the value is deprecated on a single
+// line, or an empty string when the value is not deprecated.
+func (e *EnumValue) GetDeprecationReason() string {
+	if !e.IsDeprecated {
+		return ""
+	}
+
+	reason := strings.Join(strings.Fields(e.DeprecationReason), " ")
+	if reason == "" {
+		// The default reason given by the GraphQL spec.
+		reason = "No longer supported"
+	}
+
+	return reason
+}
+
 // GetName returns a recusive lookup of the type name
 func (e *EnumValue) GetName() string {
 	var fieldName string
@@ -49,6 +49,15 @@ func (f *Field) GetTypeNameWithOverride(pkgConfig *config.PackageConfig) (string
 		}
 	}
 
+	// The built-in scalars have no generated type, so a scalar mapping is used
+	// directly as the field type.  Mappings for the other scalars are applied to
+	// the generated scalar type instead.
+	if overrideType == "" && isBuiltinScalar(nameToMatch) {
+		if scalarConfig := pkgConfig.GetScalarConfigByName(nameToMatch); scalarConfig != nil {
+			overrideType = scalarConfig.Type
+		}
+	}
+
 	// Set the typeName to the override or use what is specified in the schema.
 	if overrideType != "" {
 		typeName = overrideType
@@ -75,19 +84,15 @@ func (f *Field) GetTagsWithOverrides(parentType Type, pkgConfig *config.PackageC
 	// Get the parent type config to apply any field struct tag overrides
 	parentTypeConfig := pkgConfig.GetTypeConfigByName(parentType.Name)
 
-	var tags string
+	structTags := []string{"json"}
 	if parentTypeConfig != nil && len(parentTypeConfig.StructTags) > 0 {
-		tags = f.buildStructTags(f.Name, parentTypeConfig.StructTags)
-	}
-
-	if tags == "" {
-		return f.GetTags()
+		structTags = parentTypeConfig.StructTags
 	}
 
-	return tags
+	return f.buildStructTags(structTags, f.IsOmitEmpty(parentType))
 }
 
-func (f *Field) buildStructTags(fieldName string, structTags []string) string {
+func (f *Field) buildStructTags(structTags []string, omitEmpty bool) string {
 	tagsString := "`"
 	tagsCount := len(structTags)
 
@@ -99,7 +104,7 @@ func (f *Field) buildStructTags(fieldName string, structTags []string) string {
 
 		tagsString = tagsString + tagType + ":\"" + f.Name
 
-		if f.Type.IsInputObject() || !f.Type.IsNonNull() {
+		if omitEmpty {
 			tagsString = tagsString + ",omitempty"
 		}
 
@@ -112,27 +117,21 @@ func (f *Field) buildStructTags(fieldName string, structTags []string) string {
 	return tagsString
 }
 
-// GetTags is used to return the Go struct tags for a field.
+// GetTags is used to return the Go struct tags for a field of an output object.
 func (f *Field) GetTags() string {
 	if f == nil {
 		return ""
 	}
 
-	jsonTag := "`json:\"" + f.Name
-
-	if f.Type.IsInputObject() || !f.Type.IsNonNull() {
-		jsonTag += ",omitempty"
-	}
-
-	tags := jsonTag + "\"`"
-
-	// log.Print("\n\n **************************** \n")
-	// log.Printf("\n Struct Tags:  %s \n", f)
-	// log.Printf("\n Struct Tags:  %s \n", jsonTag)
-	// log.Print("\n **************************** \n\n")
-	// time.Sleep(5 * time.Second)
+	return f.buildStructTags([]string{"json"}, false)
+}
 
-	return tags
+// IsOmitEmpty determines if the field is left out of the encoded parent type
+// when the value is empty.  Only the nullable fields of an input object are
+// omitted, so that optional fields aren't sent with a zero value, and required
+// fields are always sent.  The fields of an output object are kept as received.
+func (f *Field) IsOmitEmpty(parentType Type) bool {
+	return parentType.Kind == KindInputObject && f.Type.Kind != KindNonNull
 }
 
 func (f *Field) IsPrimitiveType() bool {
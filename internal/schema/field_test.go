@@ -0,0 +1,60 @@
+//go:build unit
+// +build unit
+
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/tutone/internal/config"
+)
+
+func TestField_GetTagsWithOverrides(t *testing.T) {
+	t.Parallel()
+
+	nullable := Field{Name: "name", Type: TypeRef{Name: "String", Kind: KindScalar}}
+	required := Field{Name: "tags", Type: TypeRef{Kind: KindNonNull, OfType: &TypeRef{Kind: KindList, OfType: &TypeRef{Name: "TagInput", Kind: KindInputObject}}}}
+	nullableList := Field{Name: "values", Type: TypeRef{Kind: KindList, OfType: &TypeRef{Kind: KindNonNull, OfType: &TypeRef{Name: "String", Kind: KindScalar}}}}
+
+	input := Type{Name: "UserInput", Kind: KindInputObject}
+	output := Type{Name: "User", Kind: KindObject}
+
+	pkgConfig := &config.PackageConfig{
+		Types: []config.TypeConfig{
+			{Name: "UserInput", StructTags: []string{"json", "yaml"}},
+		},
+	}
+
+	cases := map[string]struct {
+		Field    Field
+		Parent   Type
+		Expected string
+	}{
+		"nullable input field": {
+			Field:    nullable,
+			Parent:   input,
+			Expected: "`json:\"name,omitempty\" yaml:\"name,omitempty\"`",
+		},
+		"required input field": {
+			Field:    required,
+			Parent:   input,
+			Expected: "`json:\"tags\" yaml:\"tags\"`",
+		},
+		"nullable list of required values": {
+			Field:    nullableList,
+			Parent:   input,
+			Expected: "`json:\"values,omitempty\" yaml:\"values,omitempty\"`",
+		},
+		"nullable output field": {
+			Field:    nullable,
+			Parent:   output,
+			Expected: "`json:\"name\"`",
+		},
+	}
+
+	for n, tc := range cases {
+		assert.Equal(t, tc.Expected, tc.Field.GetTagsWithOverrides(tc.Parent, pkgConfig), n)
+	}
+}
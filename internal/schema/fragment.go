@@ -0,0 +1,124 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Fragment is a named GraphQL fragment containing the query fields for a type.
+type Fragment struct {
+	Name     string
+	TypeName string
+	Fields   string
+	// Spreads are the names of the fragments that are spread within the Fields.
+	Spreads []string
+}
+
+// Definition returns the GraphQL fragment definition.
+func (f *Fragment) Definition() string {
+	return fmt.Sprintf("fragment %s on %s {\n%s\n}", f.Name, f.TypeName, PrefixLineTab(f.Fields))
+}
+
+// FragmentSet collects the fragments that are shared across all of the
+// operations in a package, so that the selection for a given type is only
+// defined once.  A type may have several fragments when the query depth or
+// excluded fields differ between operations.
+type FragmentSet struct {
+	sync.Mutex
+	fragments map[string]*Fragment
+	// names maps the type name and fields of a fragment to the fragment name.
+	names map[string]string
+	// variants counts the fragments for each type name.
+	variants map[string]int
+}
+
+// NewFragmentSet is to return an empty FragmentSet.
+func NewFragmentSet() *FragmentSet {
+	return &FragmentSet{
+		fragments: make(map[string]*Fragment),
+		names:     make(map[string]string),
+		variants:  make(map[string]int),
+	}
+}
+
+// Add records a fragment for the received type name and fields, returning the
+// name of the fragment.  The name of an existing fragment is returned if the
+// same fields have already been added for the type.
+func (fs *FragmentSet) Add(typeName string, fields string, spreads []string) string {
+	fs.Lock()
+	defer fs.Unlock()
+
+	key := typeName + "\n" + fields
+	if name, ok := fs.names[key]; ok {
+		return name
+	}
+
+	fs.variants[typeName]++
+
+	name := typeName + "Fields"
+	if n := fs.variants[typeName]; n > 1 {
+		name = fmt.Sprintf("%s%d", name, n)
+	}
+
+	fs.names[key] = name
+	fs.fragments[name] = &Fragment{
+		Name:     name,
+		TypeName: typeName,
+		Fields:   fields,
+		Spreads:  spreads,
+	}
+
+	return name
+}
+
+// Fragments returns all of the fragments in the set, sorted by name.
+func (fs *FragmentSet) Fragments() []*Fragment {
+	fs.Lock()
+	defer fs.Unlock()
+
+	fragments := make([]*Fragment, 0, len(fs.fragments))
+	for _, f := range fs.fragments {
+		fragments = append(fragments, f)
+	}
+
+	sort.SliceStable(fragments, func(i, j int) bool {
+		return fragments[i].Name < fragments[j].Name
+	})
+
+	return fragments
+}
+
+// Resolve returns the received fragments, and all fragments spread within
+// them, sorted by name.  GraphQL requires that every fragment used by an
+// operation is defined, and that no unused fragments are defined, so this is
+// the exact set of definitions to send along with an operation.
+func (fs *FragmentSet) Resolve(names []string) []*Fragment {
+	fs.Lock()
+	defer fs.Unlock()
+
+	seen := make(map[string]bool)
+	var fragments []*Fragment
+
+	var walk func(names []string)
+	walk = func(names []string) {
+		for _, name := range names {
+			f, ok := fs.fragments[name]
+			if !ok || seen[name] {
+				continue
+			}
+
+			seen[name] = true
+			fragments = append(fragments, f)
+			walk(f.Spreads)
+		}
+	}
+
+	walk(names)
+
+	sort.SliceStable(fragments, func(i, j int) bool {
+		return fragments[i].Name < fragments[j].Name
+	})
+
+	return fragments
+}
@@ -0,0 +1,96 @@
+//go:build unit
+// +build unit
+
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFragmentSet_Add(t *testing.T) {
+	t.Parallel()
+
+	fs := NewFragmentSet()
+
+	assert.Equal(t, "TagFields", fs.Add("Tag", "key\nvalues", nil))
+	assert.Equal(t, "TagFields", fs.Add("Tag", "key\nvalues", nil))
+	assert.Equal(t, "TagFields2", fs.Add("Tag", "key", nil))
+	assert.Equal(t, "OutlineFields", fs.Add("Outline", "tags {\n\t...TagFields\n}", []string{"TagFields"}))
+
+	fragments := fs.Fragments()
+	require.Len(t, fragments, 3)
+	assert.Equal(t, "OutlineFields", fragments[0].Name)
+	assert.Equal(t, "TagFields", fragments[1].Name)
+	assert.Equal(t, "TagFields2", fragments[2].Name)
+
+	assert.Equal(t, "fragment TagFields on Tag {\n\tkey\n\tvalues\n}", fragments[1].Definition())
+}
+
+func TestFragmentSet_Resolve(t *testing.T) {
+	t.Parallel()
+
+	fs := NewFragmentSet()
+	fs.Add("Tag", "key", nil)
+	fs.Add("Outline", "tags {\n\t...TagFields\n}", []string{"TagFields"})
+	fs.Add("Entity", "outline {\n\t...OutlineFields\n}", []string{"OutlineFields"})
+	fs.Add("User", "name", nil)
+
+	resolved := fs.Resolve([]string{"EntityFields", "TagFields"})
+
+	names := []string{}
+	for _, f := range resolved {
+		names = append(names, f.Name)
+	}
+
+	assert.Equal(t, []string{"EntityFields", "OutlineFields", "TagFields"}, names)
+	assert.Empty(t, fs.Resolve(nil))
+}
+
+func TestType_GetQueryStringFieldsWithFragments(t *testing.T) {
+	t.Parallel()
+
+	tagType := &Type{
+		Name: "Tag",
+		Kind: KindObject,
+		Fields: []Field{
+			{Name: "key", Type: TypeRef{Name: "String", Kind: KindScalar}},
+		},
+	}
+
+	outlineType := &Type{
+		Name: "Outline",
+		Kind: KindObject,
+		Fields: []Field{
+			{Name: "tags", Type: TypeRef{Kind: KindList, OfType: &TypeRef{Name: "Tag", Kind: KindObject}}},
+		},
+	}
+
+	entityType := &Type{
+		Name: "Entity",
+		Kind: KindObject,
+		Fields: []Field{
+			{Name: "name", Type: TypeRef{Name: "String", Kind: KindScalar}},
+			{Name: "outline", Type: TypeRef{Name: "Outline", Kind: KindObject}},
+			{Name: "tags", Type: TypeRef{Kind: KindList, OfType: &TypeRef{Name: "Tag", Kind: KindObject}}},
+		},
+	}
+
+	s := &Schema{Types: []*Type{tagType, outlineType, entityType}}
+	fs := NewFragmentSet()
+
+	fields, spreads := entityType.GetQueryStringFieldsWithFragments(s, 0, 3, false, nil, fs)
+
+	assert.Equal(t, "name\noutline {\n\t...OutlineFields\n}\ntags {\n\t...TagFields\n}", fields)
+	assert.Equal(t, []string{"OutlineFields", "TagFields"}, spreads)
+
+	outline := fs.Resolve([]string{"OutlineFields"})
+	require.Len(t, outline, 2)
+	assert.Equal(t, "tags {\n\t...TagFields\n}", outline[0].Fields)
+
+	// The inlined fields are unchanged.
+	inline := entityType.GetQueryStringFields(s, 0, 3, false, nil)
+	assert.Equal(t, "name\noutline {\n\ttags {\n\t\tkey\n\t}\n}\ntags {\n\tkey\n}", inline)
+}
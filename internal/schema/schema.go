@@ -346,6 +346,60 @@ func (s *Schema) GetQueryStringForEndpoint(typePath []*Type, fieldPath []string,
 	// could be located in several places in the schema.
 	t := typePath[len(typePath)-1]
 
+	fieldType, err := s.lookupEndpointFieldType(t, endpoint.Name)
+	if err != nil {
+		log.Error(err)
+		return ""
+	}
+
+	var fields string
+	if fieldType != nil && endpoint.MaxQueryFieldDepth > 0 {
+		fields = fieldType.GetQueryStringFields(s, 0, endpoint.MaxQueryFieldDepth, false, endpoint.ExcludeFields)
+	}
+
+	return s.GetQueryStringForEndpointWithSelection(typePath, fieldPath, endpoint, fields)
+}
+
+// GetQueryStringForEndpointWithFragments is the same as
+// GetQueryStringForEndpoint, except that nested selections are added to the
+// received FragmentSet and spread into the query.  The fragments that must be
+// sent along with the query are returned.
+func (s *Schema) GetQueryStringForEndpointWithFragments(typePath []*Type, fieldPath []string, endpoint config.EndpointConfig, fragments *FragmentSet) (string, []*Fragment) {
+	t := typePath[len(typePath)-1]
+
+	fieldType, err := s.lookupEndpointFieldType(t, endpoint.Name)
+	if err != nil {
+		log.Error(err)
+		return "", nil
+	}
+
+	var fields string
+	var spreads []string
+	if fieldType != nil && endpoint.MaxQueryFieldDepth > 0 {
+		fields, spreads = fieldType.GetQueryStringFieldsWithFragments(s, 0, endpoint.MaxQueryFieldDepth, false, endpoint.ExcludeFields, fragments)
+	}
+
+	return s.GetQueryStringForEndpointWithSelection(typePath, fieldPath, endpoint, fields), fragments.Resolve(spreads)
+}
+
+// lookupEndpointFieldType returns the type of the field on t by the received
+// endpoint name, or nil when t has no such field.
+func (s *Schema) lookupEndpointFieldType(t *Type, endpointName string) (*Type, error) {
+	for _, f := range t.Fields {
+		if f.Name == endpointName {
+			return s.LookupTypeByName(f.Type.GetTypeName())
+		}
+	}
+
+	return nil, nil
+}
+
+// GetQueryStringForEndpointWithSelection packs a nerdgraph query header and
+// footer around the received selection set, rather than the query fields
+// derived from the endpoint configuration.
+func (s *Schema) GetQueryStringForEndpointWithSelection(typePath []*Type, fieldPath []string, endpoint config.EndpointConfig, selection string) string {
+	t := typePath[len(typePath)-1]
+
 	data := queryStringData{}
 
 	// Set the TypeName so that we can create a special UnmarshalJSON where we need it.
@@ -383,20 +437,8 @@ func (s *Schema) GetQueryStringForEndpoint(typePath []*Type, fieldPath []string,
 	// Append all the endpoint args to the query args
 	data.QueryArgs = append(data.QueryArgs, data.EndpointArgs...)
 
-	// Match the endpoint field
-	for _, f := range t.Fields {
-		if f.Name == endpoint.Name {
-			fieldType, lookupErr := s.LookupTypeByName(f.Type.GetTypeName())
-			if lookupErr != nil {
-				log.Error(lookupErr)
-				return ""
-			}
-
-			if endpoint.MaxQueryFieldDepth > 0 {
-				data.Fields = PrefixLineTab(fieldType.GetQueryStringFields(s, 0, endpoint.MaxQueryFieldDepth, false, endpoint.ExcludeFields))
-			}
-			break
-		}
+	if selection != "" {
+		data.Fields = PrefixLineTab(selection)
 	}
 
 	tmpl, err := template.New(t.GetName()).Funcs(util.GetTemplateFuncs()).Parse(queryHeaderTemplate)
@@ -459,6 +501,36 @@ func (s *Schema) GetQueryStringForMutation(mutation *Field, cfg config.MutationC
 		"name":  mutation.Name,
 	}).Trace("GetQueryStringForMutation")
 
+	fieldType, lookupErr := s.LookupTypeByName(mutation.Type.GetTypeName())
+	if lookupErr != nil {
+		log.Error(lookupErr)
+		return ""
+	}
+
+	fields := fieldType.GetQueryStringFields(s, 0, cfg.MaxQueryFieldDepth, true, cfg.ExcludeFields)
+
+	return s.GetQueryStringForMutationWithSelection(mutation, cfg, fields)
+}
+
+// GetQueryStringForMutationWithFragments is the same as
+// GetQueryStringForMutation, except that nested selections are added to the
+// received FragmentSet and spread into the mutation.  The fragments that must
+// be sent along with the mutation are returned.
+func (s *Schema) GetQueryStringForMutationWithFragments(mutation *Field, cfg config.MutationConfig, fragments *FragmentSet) (string, []*Fragment) {
+	fieldType, lookupErr := s.LookupTypeByName(mutation.Type.GetTypeName())
+	if lookupErr != nil {
+		log.Error(lookupErr)
+		return "", nil
+	}
+
+	fields, spreads := fieldType.GetQueryStringFieldsWithFragments(s, 0, cfg.MaxQueryFieldDepth, true, cfg.ExcludeFields, fragments)
+
+	return s.GetQueryStringForMutationWithSelection(mutation, cfg, fields), fragments.Resolve(spreads)
+}
+
+// GetQueryStringForMutationWithSelection packs a nerdgraph query header and
+// footer around the received selection set for the GraphQL mutation name.
+func (s *Schema) GetQueryStringForMutationWithSelection(mutation *Field, cfg config.MutationConfig, selection string) string {
 	data := mutationStringData{
 		MutationName: mutation.Name,
 	}
@@ -477,7 +549,7 @@ func (s *Schema) GetQueryStringForMutation(mutation *Field, cfg config.MutationC
 		data.Args = append(data.Args, arg)
 	}
 
-	data.Fields = PrefixLineTab(fieldType.GetQueryStringFields(s, 0, cfg.MaxQueryFieldDepth, true, cfg.ExcludeFields))
+	data.Fields = PrefixLineTab(selection)
 	tmpl, err := template.New(fieldType.GetName()).Funcs(util.GetTemplateFuncs()).Parse(mutationHeaderTemplate)
 	if err != nil {
 		log.Error(err)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
 
@@ -97,6 +98,28 @@ func hasType(t *Type, types []*Type) bool {
 	return false
 }
 
+// Expansion expands the types of a package once, sharing the result between
+// all of the generators of the package.
+type Expansion struct {
+	once  sync.Once
+	types *[]*Type
+	err   error
+}
+
+// Types returns the result of ExpandTypes, which is only called the first
+// time.  A nil Expansion calls ExpandTypes every time.
+func (e *Expansion) Types(s *Schema, pkgConfig *config.PackageConfig) (*[]*Type, error) {
+	if e == nil {
+		return ExpandTypes(s, pkgConfig)
+	}
+
+	e.once.Do(func() {
+		e.types, e.err = ExpandTypes(s, pkgConfig)
+	})
+
+	return e.types, e.err
+}
+
 // ExpandTypes receives a set of config.TypeConfig, which is then expanded to include
 // all the nested types from the fields.
 func ExpandTypes(s *Schema, pkgConfig *config.PackageConfig) (*[]*Type, error) {
@@ -0,0 +1,194 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// builtinScalars are defined by every GraphQL server, and so are omitted from
+// the SDL.
+var builtinScalars = []string{
+	"Boolean",
+	"Float",
+	"ID",
+	"Int",
+	"String",
+}
+
+// SDL returns the GraphQL schema definition language document for the schema.
+// Descriptions and introspection types are omitted, since the document is
+// intended for validating and executing operations rather than documentation.
+func (s *Schema) SDL() string {
+	var defs []string
+
+	root := []string{}
+	if s.QueryType != nil && s.QueryType.Name != "" {
+		root = append(root, fmt.Sprintf("\tquery: %s", s.QueryType.Name))
+	}
+	if s.MutationType != nil && s.MutationType.Name != "" {
+		root = append(root, fmt.Sprintf("\tmutation: %s", s.MutationType.Name))
+	}
+	if s.SubscriptionType != nil && s.SubscriptionType.Name != "" {
+		root = append(root, fmt.Sprintf("\tsubscription: %s", s.SubscriptionType.Name))
+	}
+
+	if len(root) > 0 {
+		defs = append(defs, "schema {\n"+strings.Join(root, "\n")+"\n}")
+	}
+
+	types := make([]*Type, 0, len(s.Types))
+	for _, t := range s.Types {
+		if t == nil || strings.HasPrefix(t.Name, "__") {
+			continue
+		}
+
+		if t.Kind == KindScalar && isBuiltinScalar(t.Name) {
+			continue
+		}
+
+		types = append(types, t)
+	}
+
+	sort.SliceStable(types, func(i, j int) bool {
+		return types[i].Name < types[j].Name
+	})
+
+	for _, t := range types {
+		defs = append(defs, t.SDL())
+	}
+
+	return strings.Join(defs, "\n\n") + "\n"
+}
+
+// placeholderField and placeholderEnumValue are defined for the types without
+// any fields or values, which the parser rejects even though they are still
+// referenced by other types.
+const (
+	placeholderField     = "\t_placeholder: Boolean"
+	placeholderEnumValue = "\t_PLACEHOLDER"
+)
+
+// SDL returns the GraphQL schema definition language for the type.
+func (t *Type) SDL() string {
+	switch t.Kind {
+	case KindScalar:
+		return "scalar " + t.Name
+	case KindENUM:
+		values := make([]string, 0, len(t.EnumValues))
+		for _, v := range t.EnumValues {
+			value := "\t" + v.Name
+			if v.IsDeprecated {
+				value += fmt.Sprintf(" @deprecated(reason: %q)", v.DeprecationReason)
+			}
+			values = append(values, value)
+		}
+
+		if len(values) == 0 {
+			values = append(values, placeholderEnumValue)
+		}
+
+		return fmt.Sprintf("enum %s {\n%s\n}", t.Name, strings.Join(values, "\n"))
+	case KindUnion:
+		names := make([]string, 0, len(t.PossibleTypes))
+		for _, p := range t.PossibleTypes {
+			names = append(names, p.Name)
+		}
+
+		return fmt.Sprintf("union %s = %s", t.Name, strings.Join(names, " | "))
+	case KindInputObject:
+		if len(t.InputFields) == 0 {
+			return fmt.Sprintf("input %s {\n%s\n}", t.Name, placeholderField)
+		}
+
+		return fmt.Sprintf("input %s {\n%s\n}", t.Name, fieldsSDL(t.InputFields))
+	case KindInterface, KindObject:
+		keyword := "type"
+		if t.Kind == KindInterface {
+			keyword = "interface"
+		}
+
+		header := keyword + " " + t.Name
+		if len(t.Interfaces) > 0 {
+			names := make([]string, 0, len(t.Interfaces))
+			for _, i := range t.Interfaces {
+				names = append(names, i.Name)
+			}
+			header += " implements " + strings.Join(names, " & ")
+		}
+
+		if len(t.Fields) == 0 {
+			return fmt.Sprintf("%s {\n%s\n}", header, placeholderField)
+		}
+
+		return fmt.Sprintf("%s {\n%s\n}", header, fieldsSDL(t.Fields))
+	default:
+		return fmt.Sprintf("# unsupported kind %s for type %s", t.Kind, t.Name)
+	}
+}
+
+// SDL returns the GraphQL type reference, including the list and non-null
+// wrapping types, i.e. [String!]!
+func (r *TypeRef) SDL() string {
+	if r == nil {
+		return ""
+	}
+
+	switch r.Kind {
+	case KindNonNull:
+		return r.OfType.SDL() + "!"
+	case KindList:
+		return "[" + r.OfType.SDL() + "]"
+	default:
+		return r.Name
+	}
+}
+
+// fieldsSDL formats a set of fields, or input fields, along with any arguments.
+func fieldsSDL(fields []Field) string {
+	lines := make([]string, 0, len(fields))
+
+	for _, f := range fields {
+		line := "\t" + f.Name
+
+		if len(f.Args) > 0 {
+			args := make([]string, 0, len(f.Args))
+			for _, a := range f.Args {
+				args = append(args, inputValueSDL(a))
+			}
+			line += "(" + strings.Join(args, ", ") + ")"
+		}
+
+		line += ": " + f.Type.SDL()
+
+		// Input fields may also carry a default value.
+		if v, ok := f.DefaultValue.(string); ok && v != "" {
+			line += " = " + v
+		}
+
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func inputValueSDL(f Field) string {
+	arg := f.Name + ": " + f.Type.SDL()
+
+	// The introspection defaultValue is already formatted as a GraphQL value.
+	if v, ok := f.DefaultValue.(string); ok && v != "" {
+		arg += " = " + v
+	}
+
+	return arg
+}
+
+func isBuiltinScalar(name string) bool {
+	for _, s := range builtinScalars {
+		if s == name {
+			return true
+		}
+	}
+
+	return false
+}
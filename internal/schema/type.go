@@ -80,17 +80,39 @@ func (t *Type) IsGoType() bool {
 }
 
 func (t *Type) GetQueryStringFields(s *Schema, depth, maxDepth int, isMutation bool, excludeFields []string) string {
+	return t.queryStringFields(s, depth, maxDepth, isMutation, excludeFields, nil, nil)
+}
+
+// GetQueryStringFieldsWithFragments returns the query fields for the type,
+// where the selection of every nested object is replaced by a spread of a
+// named fragment from the received FragmentSet.  The names of the fragments
+// spread directly within the returned fields are also returned.
+func (t *Type) GetQueryStringFieldsWithFragments(s *Schema, depth, maxDepth int, isMutation bool, excludeFields []string, fragments *FragmentSet) (string, []string) {
+	spreads := []string{}
+	fields := t.queryStringFields(s, depth, maxDepth, isMutation, excludeFields, fragments, &spreads)
+
+	return fields, spreads
+}
+
+// queryStringFields builds the query fields for the type.  When fragments is
+// nil, nested selections are inlined, otherwise they are added to the
+// FragmentSet and the spread names are collected in spreads.
+func (t *Type) queryStringFields(s *Schema, depth, maxDepth int, isMutation bool, excludeFields []string, fragments *FragmentSet, spreads *[]string) string {
 	depth++
 
 	var lines []string
 
-	sort.SliceStable(t.Fields, func(i, j int) bool {
-		return t.Fields[i].Name < t.Fields[j].Name
+	// Sort a copy, since the schema is shared by concurrent generators.
+	fields := make([]Field, len(t.Fields))
+	copy(fields, t.Fields)
+
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].Name < fields[j].Name
 	})
 
 	parentFieldNames := []string{}
 
-	for _, field := range t.Fields {
+	for _, field := range fields {
 		// If any of the arguments for a given field are required, then we
 		// currently skip the field in the query since we are not handling the
 		// parameters necessary to fill that out.
@@ -132,7 +154,8 @@ func (t *Type) GetQueryStringFields(s *Schema, depth, maxDepth int, isMutation b
 
 			// Recurse first so if we have no children, we skip completely
 			// and don't end up with `field { }` (invalid)
-			subTContent := subT.GetQueryStringFields(s, depth, maxDepth, isMutation, excludeFields)
+			subSpreads := []string{}
+			subTContent := subT.queryStringFields(s, depth, maxDepth, isMutation, excludeFields, fragments, &subSpreads)
 			subTLines := strings.Split(subTContent, "\n")
 			if subTContent == "" || len(subTLines) < 1 {
 				log.WithFields(log.Fields{
@@ -143,6 +166,12 @@ func (t *Type) GetQueryStringFields(s *Schema, depth, maxDepth int, isMutation b
 				continue
 			}
 
+			if fragments != nil {
+				fragmentName := fragments.Add(subT.Name, subTContent, subSpreads)
+				*spreads = append(*spreads, fragmentName)
+				subTLines = []string{"..." + fragmentName}
+			}
+
 			// Add the field
 			lines = append(lines, field.Name+" {")
 			if lastKind == KindInterface {
@@ -171,7 +200,7 @@ func (t *Type) GetQueryStringFields(s *Schema, depth, maxDepth int, isMutation b
 		lines = append(lines, fmt.Sprintf("... on %s {", possibleType.Name))
 		lines = append(lines, "\t__typename")
 
-		possibleTContent := possibleT.GetQueryStringFields(s, depth, maxDepth, isMutation, excludeFields)
+		possibleTContent := possibleT.queryStringFields(s, depth, maxDepth, isMutation, excludeFields, fragments, spreads)
 
 		possibleTLines := strings.Split(possibleTContent, "\n")
 		for _, b := range possibleTLines {
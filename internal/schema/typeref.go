@@ -72,6 +72,8 @@ func (r *TypeRef) GetType() (string, bool, error) {
 		return "float64", false, nil
 	case "ID":
 		// ID is a nested object, but behaves like an integer.  This may be true of other SCALAR types as well, so logic here could potentially be moved.
+		// Schemas using string IDs, such as NerdGraph GUIDs, map ID to string in
+		// the scalars configuration instead.
 		return "int", false, nil
 	case "":
 		return "", true, fmt.Errorf("empty field name: %+v", r)
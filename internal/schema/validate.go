@@ -0,0 +1,143 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Validator checks that GraphQL operations are valid for a schema, using the
+// standard GraphQL validation rules.
+type Validator struct {
+	schema *ast.Schema
+}
+
+// ValidationError describes a single problem with a GraphQL operation.
+type ValidationError struct {
+	// Operation is the name used to refer to the operation, i.e. the method name.
+	Operation string
+	Rule      string
+	Message   string
+	Line      int
+	Column    int
+	// Source is the line of the operation that the error refers to.
+	Source string
+}
+
+func (e *ValidationError) Error() string {
+	msg := fmt.Sprintf("%s:%d:%d: %s", e.Operation, e.Line, e.Column, e.Message)
+	if e.Rule != "" {
+		msg += fmt.Sprintf(" [%s]", e.Rule)
+	}
+
+	if e.Source != "" {
+		msg += fmt.Sprintf("\n\t%s", strings.TrimSpace(e.Source))
+	}
+
+	return msg
+}
+
+// ValidationErrors is the set of problems found while validating operations.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+
+	return fmt.Sprintf("%d invalid GraphQL operation error(s):\n%s", len(e), strings.Join(msgs, "\n"))
+}
+
+// NewValidator returns a Validator for the received schema, or an error if the
+// schema itself could not be loaded.
+func NewValidator(s *Schema) (*Validator, error) {
+	if s == nil {
+		return nil, fmt.Errorf("unable to validate against nil schema")
+	}
+
+	astSchema, err := s.AST()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schema for validation: %s", err)
+	}
+
+	return &Validator{schema: astSchema}, nil
+}
+
+// SharedValidator creates a Validator once, sharing it between all of the
+// packages generated from the schema.
+type SharedValidator struct {
+	once      sync.Once
+	validator *Validator
+	err       error
+}
+
+// Validator returns the result of NewValidator, which is only called the first
+// time.  A nil SharedValidator calls NewValidator every time.
+func (v *SharedValidator) Validator(s *Schema) (*Validator, error) {
+	if v == nil {
+		return NewValidator(s)
+	}
+
+	v.once.Do(func() {
+		v.validator, v.err = NewValidator(s)
+	})
+
+	return v.validator, v.err
+}
+
+// AST returns the schema loaded by the GraphQL parser, for use in validating
+// and executing operations.
+func (s *Schema) AST() (*ast.Schema, error) {
+	astSchema, err := gqlparser.LoadSchema(&ast.Source{
+		Name:  "schema",
+		Input: s.SDL(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return astSchema, nil
+}
+
+// ValidateOperation parses and validates a GraphQL operation, returning
+// ValidationErrors when the operation is not valid for the schema.  The name
+// is only used to identify the operation in the errors.
+func (v *Validator) ValidateOperation(name string, operation string) error {
+	_, errs := gqlparser.LoadQuery(v.schema, operation)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return newValidationErrors(name, operation, errs)
+}
+
+func newValidationErrors(name string, operation string, errs gqlerror.List) ValidationErrors {
+	lines := strings.Split(operation, "\n")
+	result := make(ValidationErrors, 0, len(errs))
+
+	for _, e := range errs {
+		ve := &ValidationError{
+			Operation: name,
+			Rule:      e.Rule,
+			Message:   e.Message,
+		}
+
+		if len(e.Locations) > 0 {
+			ve.Line = e.Locations[0].Line
+			ve.Column = e.Locations[0].Column
+
+			if ve.Line > 0 && ve.Line <= len(lines) {
+				ve.Source = lines[ve.Line-1]
+			}
+		}
+
+		result = append(result, ve)
+	}
+
+	return result
+}
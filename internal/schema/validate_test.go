@@ -0,0 +1,234 @@
+//go:build unit
+// +build unit
+
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testValidationSchema() *Schema {
+	nonNull := func(r TypeRef) TypeRef {
+		return TypeRef{Kind: KindNonNull, OfType: &r}
+	}
+
+	queryType := &Type{
+		Name: "RootQueryType",
+		Kind: KindObject,
+		Fields: []Field{
+			{
+				Name: "entity",
+				Type: TypeRef{Name: "Entity", Kind: KindObject},
+				Args: []Field{
+					{Name: "guid", Type: nonNull(TypeRef{Name: "EntityGuid", Kind: KindScalar})},
+					{Name: "limit", Type: nonNull(TypeRef{Name: "Int", Kind: KindScalar}), DefaultValue: "10"},
+				},
+			},
+		},
+	}
+
+	mutationType := &Type{
+		Name: "RootMutationType",
+		Kind: KindObject,
+		Fields: []Field{
+			{
+				Name: "tagCreate",
+				Type: TypeRef{Name: "Entity", Kind: KindObject},
+				Args: []Field{
+					{Name: "tags", Type: nonNull(TypeRef{Kind: KindList, OfType: &TypeRef{Name: "TagInput", Kind: KindInputObject}})},
+				},
+			},
+		},
+	}
+
+	return &Schema{
+		QueryType:    queryType,
+		MutationType: mutationType,
+		Types: []*Type{
+			queryType,
+			mutationType,
+			{
+				Name: "Entity",
+				Kind: KindObject,
+				Fields: []Field{
+					{Name: "name", Type: TypeRef{Name: "String", Kind: KindScalar}},
+					{Name: "type", Type: TypeRef{Name: "EntityType", Kind: KindENUM}},
+				},
+			},
+			{
+				Name: "EntityType",
+				Kind: KindENUM,
+				EnumValues: []EnumValue{
+					{Name: "APM"},
+					{Name: "LEGACY", IsDeprecated: true, DeprecationReason: "gone"},
+				},
+			},
+			{
+				Name: "TagInput",
+				Kind: KindInputObject,
+				InputFields: []Field{
+					{Name: "key", Type: nonNull(TypeRef{Name: "String", Kind: KindScalar})},
+				},
+			},
+			{Name: "EntityGuid", Kind: KindScalar},
+			{Name: "String", Kind: KindScalar},
+			{Name: "Int", Kind: KindScalar},
+			{Name: "__Type", Kind: KindObject},
+		},
+	}
+}
+
+func TestSchema_SDL(t *testing.T) {
+	t.Parallel()
+
+	expected := `schema {
+	query: RootQueryType
+	mutation: RootMutationType
+}
+
+type Entity {
+	name: String
+	type: EntityType
+}
+
+scalar EntityGuid
+
+enum EntityType {
+	APM
+	LEGACY @deprecated(reason: "gone")
+}
+
+type RootMutationType {
+	tagCreate(tags: [TagInput]!): Entity
+}
+
+type RootQueryType {
+	entity(guid: EntityGuid!, limit: Int! = 10): Entity
+}
+
+input TagInput {
+	key: String!
+}
+`
+
+	assert.Equal(t, expected, testValidationSchema().SDL())
+}
+
+func TestNewValidator_EmptyTypes(t *testing.T) {
+	t.Parallel()
+
+	s := testValidationSchema()
+	s.Types = append(s.Types,
+		&Type{Name: "EmptyInput", Kind: KindInputObject},
+		&Type{Name: "EmptyEnum", Kind: KindENUM},
+		&Type{Name: "EmptyObject", Kind: KindObject},
+	)
+
+	// They are still referenced, by an argument and fields.
+	s.MutationType.Fields = append(s.MutationType.Fields, Field{
+		Name: "tagUpdate",
+		Type: TypeRef{Name: "Entity", Kind: KindObject},
+		Args: []Field{{Name: "options", Type: TypeRef{Name: "EmptyInput", Kind: KindInputObject}}},
+	})
+	s.Types[2].Fields = append(s.Types[2].Fields,
+		Field{Name: "status", Type: TypeRef{Name: "EmptyEnum", Kind: KindENUM}},
+		Field{Name: "metadata", Type: TypeRef{Name: "EmptyObject", Kind: KindObject}},
+	)
+
+	sdl := s.SDL()
+	assert.Contains(t, sdl, "enum EmptyEnum {\n\t_PLACEHOLDER\n}")
+	assert.Contains(t, sdl, "input EmptyInput {\n\t_placeholder: Boolean\n}")
+	assert.Contains(t, sdl, "type EmptyObject {\n\t_placeholder: Boolean\n}")
+
+	v, err := NewValidator(s)
+	require.NoError(t, err)
+
+	err = v.ValidateOperation("TagUpdate", "mutation($options: EmptyInput) { tagUpdate(options: $options) { name status } }")
+	assert.NoError(t, err)
+}
+
+func TestSharedValidator_Validator(t *testing.T) {
+	t.Parallel()
+
+	var shared SharedValidator
+
+	v, err := shared.Validator(testValidationSchema())
+	require.NoError(t, err)
+
+	again, err := shared.Validator(nil)
+	require.NoError(t, err)
+	assert.Same(t, v, again)
+}
+
+func TestValidator_ValidateOperation(t *testing.T) {
+	t.Parallel()
+
+	v, err := NewValidator(testValidationSchema())
+	require.NoError(t, err)
+
+	cases := map[string]struct {
+		Operation string
+		Rules     []string
+	}{
+		"valid": {
+			Operation: "query($guid: EntityGuid!) { entity(guid: $guid) { name type } }",
+		},
+		"validMutation": {
+			Operation: "mutation($tags: [TagInput]!) { tagCreate(tags: $tags) { name } }",
+		},
+		"unknownField": {
+			Operation: "query($guid: EntityGuid!) { entity(guid: $guid) { name guid } }",
+			Rules:     []string{"FieldsOnCorrectType"},
+		},
+		"variableType": {
+			Operation: "query($guid: String!) {\n\tentity(guid: $guid) { name }\n}",
+			Rules:     []string{"VariablesInAllowedPosition"},
+		},
+		"unusedVariable": {
+			Operation: "query($guid: EntityGuid!, $other: Int) { entity(guid: $guid) { name } }",
+			Rules:     []string{"NoUnusedVariables"},
+		},
+		"undefinedFragment": {
+			Operation: "query($guid: EntityGuid!) { entity(guid: $guid) { ...EntityFields } }",
+			Rules:     []string{"KnownFragmentNames"},
+		},
+	}
+
+	for n, tc := range cases {
+		err := v.ValidateOperation(n, tc.Operation)
+		if len(tc.Rules) == 0 {
+			assert.NoError(t, err, n)
+			continue
+		}
+
+		require.Error(t, err, n)
+
+		errs, ok := err.(ValidationErrors)
+		require.True(t, ok, n)
+
+		rules := []string{}
+		for _, e := range errs {
+			assert.Equal(t, n, e.Operation)
+			rules = append(rules, e.Rule)
+		}
+
+		assert.Equal(t, tc.Rules, rules, n)
+	}
+}
+
+func TestValidationError_Error(t *testing.T) {
+	t.Parallel()
+
+	v, err := NewValidator(testValidationSchema())
+	require.NoError(t, err)
+
+	err = v.ValidateOperation("GetEntity", "query($guid: String!) {\n\tentity(guid: $guid) { name }\n}")
+	require.Error(t, err)
+
+	assert.Equal(t, "1 invalid GraphQL operation error(s):\n"+
+		"GetEntity:2:15: Variable \"$guid\" of type \"String!\" used in position expecting type \"EntityGuid!\". [VariablesInAllowedPosition]\n"+
+		"\tentity(guid: $guid) { name }", err.Error())
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	tutoneconfig "github.com/newrelic/tutone/internal/config"
+	"github.com/newrelic/tutone/internal/schema"
+	"github.com/newrelic/tutone/internal/util"
+	"github.com/newrelic/tutone/pkg/fetch"
+)
+
+var (
+	schemaOutput string
+
+	scaffoldOptions ScaffoldOptions
+	scaffoldQuery   string
+	scaffoldSchema  string
+	scaffoldPrint   bool
+)
+
+var Command = &cobra.Command{
+	Use:   "config",
+	Short: "Work with the configuration file",
+}
+
+var schemaCommand = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of the configuration file",
+	Long: `Print the JSON Schema of the configuration file
+
+The schema describes every field of the .tutone.yml configuration
+file, allowing editors with a YAML language server to complete and
+validate the packages, generators, commands and types entries.
+`,
+	Example: "tutone config schema --output tutone.schema.json",
+	Run: func(cmd *cobra.Command, args []string) {
+		if schemaOutput == "" {
+			_, err := cmd.OutOrStdout().Write(tutoneconfig.JSONSchema)
+			util.LogIfError(log.ErrorLevel, err)
+			return
+		}
+
+		util.LogIfError(log.ErrorLevel, ioutil.WriteFile(schemaOutput, tutoneconfig.JSONSchema, 0644))
+	},
+}
+
+var addPackageCommand = &cobra.Command{
+	Use:   "add-package <name>",
+	Short: "Add a package scaffolded from the schema to the configuration file",
+	Long: `Add a package scaffolded from the schema to the configuration file
+
+The package is configured with the mutations matching a pattern, the
+query endpoints at a path, or the types named with a prefix, found in
+the cached schema.  Each mutation and endpoint selects the fields of its
+result up to the --max-depth, known scalars such as ID and
+EpochMilliseconds are overridden with their Go type, and the imports the
+generated code requires are added.
+
+The package, and any of its generators that aren't configured, are
+added at the end of their lists in the configuration file, which is
+created when it doesn't exist.  The rest of the file, including its
+comments, is left as it is.
+`,
+	Example: `tutone config add-package alerts --mutations 'alertsPolicy.*' --query actor.account.alerts
+tutone config add-package dashboards --types Dashboard --print`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		file := viper.ConfigFileUsed()
+
+		cfg := &tutoneconfig.Config{}
+		if _, err := os.Stat(file); err == nil {
+			if cfg, err = tutoneconfig.LoadConfig(file); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		schemaFile := scaffoldSchema
+		if schemaFile == "" {
+			schemaFile = cfg.Cache.SchemaFile
+		}
+
+		if schemaFile == "" {
+			schemaFile = fetch.DefaultSchemaCacheFile
+		}
+
+		s, err := schema.Load(schemaFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		options := scaffoldOptions
+		options.Name = args[0]
+		if scaffoldQuery != "" {
+			options.QueryPath = strings.Split(scaffoldQuery, ".")
+		}
+
+		pkgConfig, err := Scaffold(s, cfg, options)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if scaffoldPrint {
+			text, err := encodeYAML([]*tutoneconfig.PackageConfig{pkgConfig})
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Fprint(cmd.OutOrStdout(), text)
+			return
+		}
+
+		generators := make([]tutoneconfig.GeneratorConfig, 0, len(pkgConfig.Generators))
+		for _, name := range pkgConfig.Generators {
+			generators = append(generators, tutoneconfig.GeneratorConfig{Name: name})
+		}
+
+		if err := AddPackage(file, pkgConfig, generators); err != nil {
+			log.Fatal(err)
+		}
+
+		log.WithFields(log.Fields{
+			"file":    file,
+			"package": pkgConfig.Name,
+		}).Info("package added")
+	},
+}
+
+func init() {
+	Command.AddCommand(schemaCommand)
+	Command.AddCommand(addPackageCommand)
+
+	schemaCommand.Flags().StringVarP(&schemaOutput, "output", "o", "", "File to write the schema to, defaults to stdout")
+
+	addPackageCommand.Flags().StringVarP(&scaffoldOptions.Mutations, "mutations", "m", "", "Pattern of the mutations to add")
+	addPackageCommand.Flags().StringVarP(&scaffoldQuery, "query", "q", "", "Dot separated path of fields to the query endpoints to add, i.e. actor.cloud")
+	addPackageCommand.Flags().StringVarP(&scaffoldOptions.TypePrefix, "types", "t", "", "Prefix of the names of the types to add")
+	addPackageCommand.Flags().StringVar(&scaffoldOptions.Path, "path", "", "Path of the package, defaults to pkg/<name>")
+	addPackageCommand.Flags().StringVar(&scaffoldOptions.ImportPath, "import-path", "", "Import path of the package, defaults to the module of the configured packages")
+	addPackageCommand.Flags().StringSliceVarP(&scaffoldOptions.Generators, "generators", "g", nil, "Generators of the package, defaults to typegen, and nerdgraphclient for mutations and queries")
+	addPackageCommand.Flags().IntVar(&scaffoldOptions.MaxQueryFieldDepth, "max-depth", DefaultMaxQueryFieldDepth, "Deepest max_query_field_depth of the mutations and query endpoints")
+	addPackageCommand.Flags().StringVarP(&scaffoldSchema, "schema", "s", "", "Schema file to scaffold from, defaults to the cached schema file")
+	addPackageCommand.Flags().BoolVar(&scaffoldPrint, "print", false, "Print the package instead of adding it to the configuration file")
+}
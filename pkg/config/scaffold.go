@@ -0,0 +1,594 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	tutoneconfig "github.com/newrelic/tutone/internal/config"
+	"github.com/newrelic/tutone/internal/schema"
+	"github.com/newrelic/tutone/internal/util"
+)
+
+// DefaultMaxQueryFieldDepth is the deepest max_query_field_depth scaffolded
+// for a mutation or query endpoint.
+const DefaultMaxQueryFieldDepth = 2
+
+const (
+	clientErrorsImport = "github.com/newrelic/newrelic-client-go/pkg/errors"
+	nrtimeImport       = "github.com/newrelic/newrelic-client-go/pkg/nrtime"
+	commonImport       = "github.com/newrelic/newrelic-client-go/pkg/common"
+)
+
+// knownScalar is the Go type used in place of a scalar of the schema.
+type knownScalar struct {
+	Type   string
+	Import string
+}
+
+// knownScalars are the scalars of NerdGraph with a Go type in the client.
+var knownScalars = map[string]knownScalar{
+	"AttributeMap":      {Type: "map[string]interface{}"},
+	"DateTime":          {Type: "*nrtime.DateTime", Import: nrtimeImport},
+	"EntityGuid":        {Type: "common.EntityGUID", Import: commonImport},
+	"EpochMilliseconds": {Type: "*nrtime.EpochMilliseconds", Import: nrtimeImport},
+	"EpochSeconds":      {Type: "*nrtime.EpochSeconds", Import: nrtimeImport},
+	"ID":                {Type: "string"},
+	"Milliseconds":      {Type: "int"},
+	"Seconds":           {Type: "int"},
+}
+
+// ScaffoldOptions select the parts of the schema a package is scaffolded from.
+// At least one of the Mutations, QueryPath or TypePrefix is required.
+type ScaffoldOptions struct {
+	Name       string
+	Path       string
+	ImportPath string
+	Generators []string
+	// Mutations is a pattern of the names of the mutations to add.
+	Mutations string
+	// QueryPath is the path of field names to the type whose fields are added
+	// as query endpoints.
+	QueryPath []string
+	// TypePrefix adds the types whose names start with it.
+	TypePrefix string
+	// MaxQueryFieldDepth limits the depth scaffolded for each mutation and
+	// query endpoint, defaulting to DefaultMaxQueryFieldDepth.
+	MaxQueryFieldDepth int
+}
+
+// Scaffold returns the configuration of a package, with the mutations, query
+// endpoints and types selected by the options.  Each mutation and endpoint
+// selects the fields of its result as deep as they go, up to the maximum
+// depth, and the known scalars that aren't mapped by the config are overridden
+// with their Go type, along with the imports the generated code requires.
+func Scaffold(s *schema.Schema, cfg *tutoneconfig.Config, options ScaffoldOptions) (*tutoneconfig.PackageConfig, error) {
+	if options.Name == "" {
+		return nil, errors.New("package name required")
+	}
+
+	if options.Mutations == "" && len(options.QueryPath) == 0 && options.TypePrefix == "" {
+		return nil, errors.New("a mutation pattern, query path or type prefix is required")
+	}
+
+	maxDepth := options.MaxQueryFieldDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxQueryFieldDepth
+	}
+
+	pkgConfig := &tutoneconfig.PackageConfig{
+		Name:       options.Name,
+		Path:       options.Path,
+		ImportPath: options.ImportPath,
+		Generators: options.Generators,
+	}
+
+	if pkgConfig.Path == "" {
+		pkgConfig.Path = "pkg/" + strings.ToLower(options.Name)
+	}
+
+	if pkgConfig.ImportPath == "" {
+		pkgConfig.ImportPath = defaultImportPath(cfg, pkgConfig.Path)
+	}
+
+	if options.Mutations != "" {
+		mutations := s.LookupMutationsByPattern(options.Mutations)
+		if len(mutations) == 0 {
+			return nil, fmt.Errorf("no mutation matching %s in the schema", options.Mutations)
+		}
+
+		for _, m := range mutations {
+			pkgConfig.Mutations = append(pkgConfig.Mutations, tutoneconfig.MutationConfig{
+				Name:               m.Name,
+				MaxQueryFieldDepth: queryFieldDepth(s, m.Type, maxDepth),
+			})
+		}
+	}
+
+	if len(options.QueryPath) > 0 {
+		types, err := s.LookupQueryTypesByFieldPath(options.QueryPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query path %s: %w", strings.Join(options.QueryPath, "."), err)
+		}
+
+		query := tutoneconfig.Query{Path: options.QueryPath}
+		for _, f := range types[len(types)-1].Fields {
+			query.Endpoints = append(query.Endpoints, tutoneconfig.EndpointConfig{
+				Name:               f.Name,
+				MaxQueryFieldDepth: queryFieldDepth(s, f.Type, maxDepth),
+			})
+		}
+
+		pkgConfig.Queries = append(pkgConfig.Queries, query)
+	}
+
+	if options.TypePrefix != "" {
+		for _, t := range s.Types {
+			if t != nil && strings.HasPrefix(t.Name, options.TypePrefix) {
+				pkgConfig.Types = append(pkgConfig.Types, tutoneconfig.TypeConfig{Name: t.Name})
+			}
+		}
+
+		if len(pkgConfig.Types) == 0 {
+			return nil, fmt.Errorf("no type named with the prefix %s in the schema", options.TypePrefix)
+		}
+	}
+
+	if len(pkgConfig.Generators) == 0 {
+		pkgConfig.Generators = []string{"typegen"}
+
+		if len(pkgConfig.Mutations) > 0 || len(pkgConfig.Queries) > 0 {
+			pkgConfig.Generators = append(pkgConfig.Generators, "nerdgraphclient")
+		}
+	}
+
+	if err := scaffoldTypes(s, cfg, pkgConfig); err != nil {
+		return nil, err
+	}
+
+	return pkgConfig, nil
+}
+
+// scaffoldTypes overrides the known scalars used by the package, and adds the
+// imports required by the overrides and the generated code.
+func scaffoldTypes(s *schema.Schema, cfg *tutoneconfig.Config, pkgConfig *tutoneconfig.PackageConfig) error {
+	expandedTypes, err := schema.ExpandTypes(s, pkgConfig)
+	if err != nil {
+		return err
+	}
+
+	var imports []string
+	addImport := func(i string) {
+		if i != "" && !util.StringInStrings(i, imports) {
+			imports = append(imports, i)
+		}
+	}
+
+	for _, t := range *expandedTypes {
+		switch t.Kind {
+		case schema.KindInterface:
+			// The interfaces are decoded by their __typename.
+			addImport("encoding/json")
+			addImport("fmt")
+		case schema.KindScalar:
+			scalar, ok := knownScalars[t.Name]
+			if !ok || scalarConfigured(cfg, t.Name) {
+				continue
+			}
+
+			typeConfig := findTypeConfig(pkgConfig, t.Name)
+			typeConfig.FieldTypeOverride = scalar.Type
+			typeConfig.SkipTypeCreate = true
+
+			addImport(scalar.Import)
+		}
+	}
+
+	if len(pkgConfig.Queries) > 0 && util.StringInStrings("nerdgraphclient", pkgConfig.Generators) {
+		addImport(clientErrorsImport)
+	}
+
+	sort.Strings(imports)
+	pkgConfig.Imports = imports
+
+	sort.SliceStable(pkgConfig.Types, func(i, j int) bool {
+		return pkgConfig.Types[i].Name < pkgConfig.Types[j].Name
+	})
+
+	return nil
+}
+
+// scalarConfigured determines if the Go type of the scalar is configured for
+// every package.
+func scalarConfigured(cfg *tutoneconfig.Config, name string) bool {
+	for _, scalarConfig := range cfg.Scalars {
+		if scalarConfig.Name == name {
+			return true
+		}
+	}
+
+	return cfg.PackageDefaults.GetScalarConfigByName(name) != nil
+}
+
+// findTypeConfig returns the configuration of the type, which is added to the
+// package when it isn't already configured.
+func findTypeConfig(pkgConfig *tutoneconfig.PackageConfig, name string) *tutoneconfig.TypeConfig {
+	for i := range pkgConfig.Types {
+		if pkgConfig.Types[i].Name == name {
+			return &pkgConfig.Types[i]
+		}
+	}
+
+	pkgConfig.Types = append(pkgConfig.Types, tutoneconfig.TypeConfig{Name: name})
+
+	return &pkgConfig.Types[len(pkgConfig.Types)-1]
+}
+
+// queryFieldDepth returns the depth of the nested objects of the type, up to
+// the maxDepth, and at least one so that the fields of an endpoint are
+// selected.
+func queryFieldDepth(s *schema.Schema, typeRef schema.TypeRef, maxDepth int) int {
+	t, err := s.LookupTypeByName(typeRef.GetTypeName())
+	if err != nil {
+		return 1
+	}
+
+	depth := objectDepth(s, t, maxDepth)
+	if depth < 1 {
+		return 1
+	}
+
+	return depth
+}
+
+// objectDepth returns the number of levels of object fields below the type,
+// up to the maxDepth.  Fields with required arguments are not selected, so are
+// ignored.
+func objectDepth(s *schema.Schema, t *schema.Type, maxDepth int) int {
+	depth := 0
+
+	for _, f := range t.Fields {
+		if depth == maxDepth {
+			break
+		}
+
+		kinds := f.Type.GetKinds()
+		if kind := kinds[len(kinds)-1]; kind != schema.KindObject && kind != schema.KindInterface {
+			continue
+		}
+
+		if f.HasRequiredArg() {
+			continue
+		}
+
+		fieldType, err := s.LookupTypeByName(f.Type.GetTypeName())
+		if err != nil {
+			continue
+		}
+
+		if d := 1 + objectDepth(s, fieldType, maxDepth-1); d > depth {
+			depth = d
+		}
+	}
+
+	return depth
+}
+
+// defaultImportPath returns the import path of the package path, within the
+// module of the configured packages whose import path ends with their path.
+func defaultImportPath(cfg *tutoneconfig.Config, path string) string {
+	for _, p := range cfg.Packages {
+		if p.Path != "" && strings.HasSuffix(p.ImportPath, "/"+p.Path) {
+			return strings.TrimSuffix(p.ImportPath, p.Path) + path
+		}
+	}
+
+	return ""
+}
+
+// AddPackage adds the package to the configuration file, along with any of the
+// generators that aren't already configured.  The file is created when it
+// doesn't exist.  The package and generators are inserted at the end of their
+// lists, leaving the rest of the file, including comments, as it is.
+func AddPackage(file string, pkgConfig *tutoneconfig.PackageConfig, generators []tutoneconfig.GeneratorConfig) error {
+	content, err := ioutil.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	content, err = addPackage(content, pkgConfig, generators)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	return ioutil.WriteFile(file, content, 0644)
+}
+
+// addPackage returns the content of the configuration file with the package
+// and generators added, see AddPackage.
+func addPackage(content []byte, pkgConfig *tutoneconfig.PackageConfig, generators []tutoneconfig.GeneratorConfig) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+
+	if len(doc.Content) == 0 {
+		doc.Kind = yaml.DocumentNode
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, errors.New("the configuration is not a mapping")
+	}
+
+	configured := map[string]bool{}
+	if packages := mappingValue(root, "packages"); packages != nil {
+		for _, p := range packages.Content {
+			if name := mappingValue(p, "name"); name != nil && name.Value == pkgConfig.Name {
+				return nil, fmt.Errorf("package %s is already configured", pkgConfig.Name)
+			}
+		}
+	}
+
+	if existing := mappingValue(root, "generators"); existing != nil {
+		for _, g := range existing.Content {
+			if name := mappingValue(g, "name"); name != nil {
+				configured[name.Value] = true
+			}
+		}
+	}
+
+	lists := []list{{key: "packages", items: []*tutoneconfig.PackageConfig{pkgConfig}}}
+
+	var missing []tutoneconfig.GeneratorConfig
+	for _, g := range generators {
+		if !configured[g.Name] {
+			missing = append(missing, g)
+		}
+	}
+
+	if len(missing) > 0 {
+		lists = append(lists, list{key: "generators", items: missing})
+	}
+
+	var inserts []insert
+
+	for _, l := range lists {
+		i, err := listInsert(root, l)
+		if err != nil {
+			return nil, err
+		}
+
+		if i == nil {
+			// The list can't be extended in place, so the whole file is encoded.
+			return encodeWithLists(&doc, root, lists)
+		}
+
+		inserts = append(inserts, *i)
+	}
+
+	return applyInserts(content, inserts), nil
+}
+
+// list is the items added to the sequence of a key of the configuration.
+type list struct {
+	key   string
+	items interface{}
+}
+
+// insert is text inserted after a line of the file, or at the end of the file
+// when the line is zero.
+type insert struct {
+	line int
+	text string
+}
+
+// listInsert returns the insert of the items at the end of the block sequence
+// of the key, or at the end of the file when the key isn't set.  Nil is
+// returned when the sequence is empty or in the flow style.
+func listInsert(root *yaml.Node, l list) (*insert, error) {
+	value := mappingValue(root, l.key)
+
+	if value == nil {
+		text, err := encodeYAML(map[string]interface{}{l.key: l.items})
+		if err != nil {
+			return nil, err
+		}
+
+		return &insert{text: text}, nil
+	}
+
+	if value.Kind != yaml.SequenceNode || value.Style&yaml.FlowStyle != 0 || len(value.Content) == 0 {
+		return nil, nil
+	}
+
+	text, err := encodeYAML(l.items)
+	if err != nil {
+		return nil, err
+	}
+
+	// Indent the items to the dash of the existing items.
+	indent := strings.Repeat(" ", value.Content[0].Column-3)
+	lines := strings.SplitAfter(text, "\n")
+	for i, l := range lines {
+		if l != "" {
+			lines[i] = indent + l
+		}
+	}
+
+	return &insert{line: lastLine(value), text: strings.Join(lines, "")}, nil
+}
+
+// applyInserts inserts the text after their lines, from the last line so that
+// the earlier lines don't move.
+func applyInserts(content []byte, inserts []insert) []byte {
+	lines := strings.SplitAfter(string(content), "\n")
+
+	sort.SliceStable(inserts, func(i, j int) bool {
+		return insertLine(inserts[i], len(lines)) > insertLine(inserts[j], len(lines))
+	})
+
+	for _, i := range inserts {
+		line := insertLine(i, len(lines))
+
+		// The inserted text starts on a line of its own.
+		if line > 0 && lines[line-1] != "" && !strings.HasSuffix(lines[line-1], "\n") {
+			lines[line-1] += "\n"
+		}
+
+		lines = append(lines[:line], append([]string{i.text}, lines[line:]...)...)
+	}
+
+	return []byte(strings.Join(lines, ""))
+}
+
+func insertLine(i insert, lineCount int) int {
+	if i.line == 0 || i.line > lineCount {
+		return lineCount
+	}
+
+	return i.line
+}
+
+// encodeWithLists adds the items to the document, which is encoded again.
+func encodeWithLists(doc *yaml.Node, root *yaml.Node, lists []list) ([]byte, error) {
+	for _, l := range lists {
+		items, err := encodeNode(l.items)
+		if err != nil {
+			return nil, err
+		}
+
+		key, value := mappingKey(root, l.key), mappingValue(root, l.key)
+		if value == nil {
+			root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: l.key}, items)
+			continue
+		}
+
+		// The comment on the line of an empty list stays on the line of the key.
+		if key.LineComment == "" {
+			key.LineComment = value.LineComment
+		}
+
+		value.LineComment = ""
+
+		if value.Kind != yaml.SequenceNode {
+			*value = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		}
+
+		value.Style = 0
+		value.Content = append(value.Content, items.Content...)
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), encoder.Close()
+}
+
+// encodeYAML encodes the value in the style of the configuration file.
+func encodeYAML(v interface{}) (string, error) {
+	n, err := encodeNode(v)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(n); err != nil {
+		return "", err
+	}
+
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// encodeNode encodes the value as a node in the style of the configuration
+// file.
+func encodeNode(v interface{}) (*yaml.Node, error) {
+	var n yaml.Node
+	if err := n.Encode(v); err != nil {
+		return nil, err
+	}
+
+	// Query paths are written inline, i.e. path: ["actor", "cloud"]
+	var flowPaths func(n *yaml.Node)
+	flowPaths = func(n *yaml.Node) {
+		if n.Kind == yaml.MappingNode {
+			for i := 0; i < len(n.Content)-1; i += 2 {
+				if n.Content[i].Value == "path" && n.Content[i+1].Kind == yaml.SequenceNode {
+					n.Content[i+1].Style = yaml.FlowStyle
+				}
+			}
+		}
+
+		for _, c := range n.Content {
+			flowPaths(c)
+		}
+	}
+	flowPaths(&n)
+
+	return &n, nil
+}
+
+// mappingKey returns the node of the key of the mapping node, or nil.
+func mappingKey(n *yaml.Node, key string) *yaml.Node {
+	if n.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i < len(n.Content)-1; i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i]
+		}
+	}
+
+	return nil
+}
+
+// mappingValue returns the value of the key of the mapping node, or nil.
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	if n.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i < len(n.Content)-1; i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// lastLine returns the last line of the node, including its children.
+func lastLine(n *yaml.Node) int {
+	line := n.Line
+	if n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+		line += strings.Count(strings.TrimSuffix(n.Value, "\n"), "\n") + 1
+	}
+
+	for _, c := range n.Content {
+		if l := lastLine(c); l > line {
+			line = l
+		}
+	}
+
+	return line
+}
@@ -0,0 +1,157 @@
+//go:build unit
+// +build unit
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tutoneconfig "github.com/newrelic/tutone/internal/config"
+	"github.com/newrelic/tutone/internal/schema"
+)
+
+var testSchema = &schema.Schema{
+	MutationType: &schema.Type{
+		Name: "RootMutationType",
+		Fields: []schema.Field{
+			{Name: "alertsPolicyCreate", Type: schema.TypeRef{Name: "AlertsPolicy", Kind: schema.KindObject}},
+			{Name: "alertsPolicyDelete", Type: schema.TypeRef{Name: "ID", Kind: schema.KindScalar}},
+			{Name: "dashboardCreate", Type: schema.TypeRef{Name: "ID", Kind: schema.KindScalar}},
+		},
+	},
+	Types: []*schema.Type{
+		{Name: "RootQueryType", Kind: schema.KindObject, Fields: []schema.Field{{Name: "actor", Type: schema.TypeRef{Name: "Actor", Kind: schema.KindObject}}}},
+		{Name: "Actor", Kind: schema.KindObject, Fields: []schema.Field{{Name: "policies", Type: schema.TypeRef{Name: "AlertsPolicy", Kind: schema.KindObject}}}},
+		{Name: "AlertsPolicy", Kind: schema.KindObject, Fields: []schema.Field{
+			{Name: "id", Type: schema.TypeRef{Name: "ID", Kind: schema.KindScalar}},
+			{Name: "createdAt", Type: schema.TypeRef{Name: "EpochMilliseconds", Kind: schema.KindScalar}},
+			{Name: "conditions", Type: schema.TypeRef{Name: "AlertsCondition", Kind: schema.KindObject}},
+		}},
+		{Name: "AlertsCondition", Kind: schema.KindObject, Fields: []schema.Field{
+			{Name: "policy", Type: schema.TypeRef{Name: "AlertsPolicy", Kind: schema.KindObject}},
+		}},
+		{Name: "ID", Kind: schema.KindScalar},
+		{Name: "EpochMilliseconds", Kind: schema.KindScalar},
+	},
+}
+
+func TestScaffold(t *testing.T) {
+	t.Parallel()
+
+	cfg := &tutoneconfig.Config{
+		Packages: []tutoneconfig.PackageConfig{{Name: "cloud", Path: "pkg/cloud", ImportPath: "github.com/newrelic/newrelic-client-go/pkg/cloud"}},
+		Scalars:  []tutoneconfig.ScalarConfig{{Name: "EpochMilliseconds", Type: "time.Time"}},
+	}
+
+	pkgConfig, err := Scaffold(testSchema, cfg, ScaffoldOptions{
+		Name:      "alerts",
+		Mutations: "alertsPolicy.*",
+		QueryPath: []string{"actor"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, &tutoneconfig.PackageConfig{
+		Name:       "alerts",
+		Path:       "pkg/alerts",
+		ImportPath: "github.com/newrelic/newrelic-client-go/pkg/alerts",
+		Types: []tutoneconfig.TypeConfig{
+			{Name: "ID", FieldTypeOverride: "string", SkipTypeCreate: true},
+		},
+		Mutations: []tutoneconfig.MutationConfig{
+			{Name: "alertsPolicyCreate", MaxQueryFieldDepth: 2},
+			{Name: "alertsPolicyDelete", MaxQueryFieldDepth: 1},
+		},
+		Queries: []tutoneconfig.Query{{
+			Path:      []string{"actor"},
+			Endpoints: []tutoneconfig.EndpointConfig{{Name: "policies", MaxQueryFieldDepth: 2}},
+		}},
+		Generators: []string{"typegen", "nerdgraphclient"},
+		Imports:    []string{clientErrorsImport},
+	}, pkgConfig)
+
+	pkgConfig, err = Scaffold(testSchema, &tutoneconfig.Config{}, ScaffoldOptions{Name: "alerts", TypePrefix: "Alerts"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []tutoneconfig.TypeConfig{
+		{Name: "AlertsCondition"},
+		{Name: "AlertsPolicy"},
+		{Name: "EpochMilliseconds", FieldTypeOverride: "*nrtime.EpochMilliseconds", SkipTypeCreate: true},
+		{Name: "ID", FieldTypeOverride: "string", SkipTypeCreate: true},
+	}, pkgConfig.Types)
+	assert.Equal(t, []string{"typegen"}, pkgConfig.Generators)
+	assert.Equal(t, []string{nrtimeImport}, pkgConfig.Imports)
+
+	_, err = Scaffold(testSchema, cfg, ScaffoldOptions{Name: "alerts", Mutations: "missing"})
+	assert.EqualError(t, err, "no mutation matching missing in the schema")
+
+	_, err = Scaffold(testSchema, cfg, ScaffoldOptions{Name: "alerts"})
+	assert.Error(t, err)
+}
+
+func TestAddPackage(t *testing.T) {
+	t.Parallel()
+
+	pkgConfig := &tutoneconfig.PackageConfig{
+		Name:       "alerts",
+		Generators: []string{"typegen", "nerdgraphclient"},
+		Queries:    []tutoneconfig.Query{{Path: []string{"actor", "account"}}},
+	}
+	generators := []tutoneconfig.GeneratorConfig{{Name: "typegen"}, {Name: "nerdgraphclient"}}
+
+	content := `---
+# The packages
+packages:
+  - name: cloud # the cloud integrations
+    description: |
+      Multiple
+      lines
+
+# The generators
+generators:
+  - name: typegen
+`
+
+	result, err := addPackage([]byte(content), pkgConfig, generators)
+	require.NoError(t, err)
+	assert.Equal(t, `---
+# The packages
+packages:
+  - name: cloud # the cloud integrations
+    description: |
+      Multiple
+      lines
+  - name: alerts
+    generators:
+      - typegen
+      - nerdgraphclient
+    queries:
+      - path: [actor, account]
+
+# The generators
+generators:
+  - name: typegen
+  - name: nerdgraphclient
+`, string(result))
+
+	_, err = addPackage(result, pkgConfig, generators)
+	assert.EqualError(t, err, "package alerts is already configured")
+
+	// Empty lists are encoded again
+	result, err = addPackage([]byte("packages: [] # none\n"), pkgConfig, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `packages: # none
+  - name: alerts
+    generators:
+      - typegen
+      - nerdgraphclient
+    queries:
+      - path: [actor, account]
+`, string(result))
+
+	result, err = addPackage(nil, &tutoneconfig.PackageConfig{Name: "alerts"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "packages:\n  - name: alerts\n", string(result))
+}
@@ -1,6 +1,8 @@
 package fetch
 
 import (
+	"context"
+	"fmt"
 	"os"
 
 	log "github.com/sirupsen/logrus"
@@ -26,7 +28,8 @@ Query the GraphQL server for schema and write it to a file.
 `,
 	Example: "tutone fetch --config configs/tutone.yaml",
 	Run: func(cmd *cobra.Command, args []string) {
-		Fetch(
+		err := Fetch(
+			cmd.Context(),
 			viper.GetString("endpoint"),
 			viper.GetBool("auth.disable"),
 			viper.GetString("auth.header"),
@@ -34,18 +37,25 @@ Query the GraphQL server for schema and write it to a file.
 			viper.GetString("cache.schema_file"),
 			refetch,
 		)
+		if err != nil {
+			log.Fatal(err)
+		}
 	},
 }
 
+// Fetch saves the schema of the endpoint to the schemaFile, unless the file
+// already exists and refetch is false.
 func Fetch(
+	ctx context.Context,
 	endpoint string,
 	disableAuth bool,
 	authHeader string,
 	authEnvVariableName string,
 	schemaFile string,
 	refetch bool,
-) {
+) error {
 	e := NewEndpoint()
+	e.Context = ctx
 	e.URL = endpoint
 	e.Auth.Disable = disableAuth
 	e.Auth.Header = authHeader
@@ -56,11 +66,13 @@ func Fetch(
 	if os.IsNotExist(err) || refetch {
 		schema, err := e.Fetch()
 		if err != nil {
-			log.Fatal(err)
+			return fmt.Errorf("failed to fetch schema from %s: %w", endpoint, err)
 		}
 
 		if schemaFile != "" {
-			util.LogIfError(log.ErrorLevel, schema.Save(schemaFile))
+			if err := schema.Save(schemaFile); err != nil {
+				return err
+			}
 		}
 
 		log.WithFields(log.Fields{
@@ -68,6 +80,8 @@ func Fetch(
 			"schema_file": schemaFile,
 		}).Info("successfully fetched schema")
 	}
+
+	return nil
 }
 
 func init() {
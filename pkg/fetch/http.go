@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"time"
 
@@ -20,14 +21,17 @@ const (
 )
 
 type GraphqlQuery struct {
-	Query     string      `json:"query"`
-	Variables interface{} `json:"variables"` // map[string]interface really...
+	Query         string      `json:"query"`
+	Variables     interface{} `json:"variables"` // map[string]interface really...
+	OperationName string      `json:"operationName,omitempty"`
 }
 
 type Endpoint struct {
 	URL  string
 	Auth AuthConfig
 	HTTP HTTPConfig
+	// Context is used for every request, defaulting to context.Background().
+	Context context.Context
 }
 
 type AuthConfig struct {
@@ -145,6 +149,30 @@ func (e *Endpoint) FetchType(name string) (*schema.Type, error) {
 //
 // fetch does the heavy lifting to return the schema data
 func (e *Endpoint) fetch(query GraphqlQuery) (*schema.QueryResponse, error) {
+	resp, err := e.do(query)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return schema.ParseResponse(resp)
+}
+
+// Query sends an arbitrary GraphQL operation to the endpoint, returning the
+// raw response body.  GraphQL errors are part of the body, and are not
+// returned as an error.
+func (e *Endpoint) Query(query GraphqlQuery) ([]byte, error) {
+	resp, err := e.do(query)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// do sends the query, returning the response when the request succeeded.
+func (e *Endpoint) do(query GraphqlQuery) (*http.Response, error) {
 	if e.URL == "" {
 		return nil, errors.New("unable to fetch from empty URL")
 	}
@@ -158,7 +186,12 @@ func (e *Endpoint) fetch(query GraphqlQuery) (*schema.QueryResponse, error) {
 	}).Trace("using query")
 	reqBody := bytes.NewBuffer(j)
 
-	req, err := http.NewRequestWithContext(context.Background(), "POST", e.URL, reqBody)
+	ctx := e.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", e.URL, reqBody)
 	if err != nil {
 		return nil, err
 	}
@@ -192,14 +225,14 @@ func (e *Endpoint) fetch(query GraphqlQuery) (*schema.QueryResponse, error) {
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	log.WithFields(log.Fields{
 		"status_code": resp.StatusCode,
 	}).Debug("request completed")
 	if resp.StatusCode != 200 {
+		resp.Body.Close()
 		return nil, fmt.Errorf("unexpected HTTP status code: %d", resp.StatusCode)
 	}
 
-	return schema.ParseResponse(resp)
+	return resp, nil
 }
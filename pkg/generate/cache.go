@@ -0,0 +1,269 @@
+package generate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/newrelic/tutone/internal/codegen"
+	"github.com/newrelic/tutone/internal/config"
+	"github.com/newrelic/tutone/internal/schema"
+	"github.com/newrelic/tutone/internal/version"
+	"github.com/newrelic/tutone/templates"
+)
+
+// DefaultCacheFile is where the fingerprints of the packages are stored, in
+// the same directory as the cached schema.
+const DefaultCacheFile = ".tutone.cache.json"
+
+// errUncacheable is returned when the inputs of a package can't be known
+// before generating it.
+var errUncacheable = errors.New("package can't be cached")
+
+// Cache records a fingerprint of the inputs of each package, so that the
+// packages whose inputs haven't changed since they were last generated can be
+// skipped.
+type Cache struct {
+	Packages map[string]string `json:"packages"`
+}
+
+// cacheFile returns the location of the cache for the schema file.
+func cacheFile(schemaFile string) string {
+	return filepath.Join(filepath.Dir(schemaFile), DefaultCacheFile)
+}
+
+// LoadCache reads the cache from the file, returning an empty Cache when the
+// file does not exist.
+func LoadCache(file string) (*Cache, error) {
+	c := &Cache{Packages: map[string]string{}}
+
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+
+		return nil, err
+	}
+
+	if err := json.Unmarshal(content, c); err != nil {
+		return nil, err
+	}
+
+	if c.Packages == nil {
+		c.Packages = map[string]string{}
+	}
+
+	return c, nil
+}
+
+// Save writes the cache to the file.
+func (c *Cache) Save(file string) error {
+	content, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, append(content, '\n'), 0644)
+}
+
+// fingerprintInputs are everything that the generated code of a package
+// depends on.
+type fingerprintInputs struct {
+	Version    string                    `json:"version"`
+	Package    *config.PackageConfig     `json:"package"`
+	Generators []*config.GeneratorConfig `json:"generators"`
+	Templates  map[string]string         `json:"templates"`
+	Schema     interface{}               `json:"schema"`
+}
+
+// schemaInputs are the parts of the schema used by a package.
+type schemaInputs struct {
+	Types      []*schema.Type   `json:"types"`
+	Mutations  []schema.Field   `json:"mutations"`
+	QueryPaths [][]*schema.Type `json:"query_paths"`
+}
+
+// fingerprint returns a hash of the inputs of the package: its config, the
+// config of its generators, their templates and the part of the schema that
+// the expanded types of the package touch.  The command and terraform
+// generators and plugins may use any part of the schema, so the hash of the
+// whole schema is used for their packages.  Packages using a remote template
+// return errUncacheable.
+func fingerprint(s *schema.Schema, schemaHash string, cfg *config.Config, pkgConfig *config.PackageConfig, expansion *schema.Expansion) (string, error) {
+	inputs := fingerprintInputs{
+		Version:   version.Version,
+		Package:   pkgConfig,
+		Templates: map[string]string{},
+	}
+
+	wholeSchema := false
+
+	for _, generatorName := range pkgConfig.Generators {
+		genConfig, err := getGeneratorConfigByName(generatorName, cfg.Generators)
+		if err != nil {
+			return "", err
+		}
+
+		if genConfig.TemplateURL != "" {
+			return "", errUncacheable
+		}
+
+		if genConfig.Plugin != "" || generatorName == "command" || generatorName == "terraform" {
+			wholeSchema = true
+		}
+
+		if genConfig.Plugin != "" {
+			executable, err := exec.LookPath(genConfig.Plugin)
+			if err != nil {
+				return "", err
+			}
+
+			if err := hashFile(inputs.Templates, executable); err != nil {
+				return "", err
+			}
+		}
+
+		if genConfig.TemplateDir != "" {
+			if err := hashTemplateDir(inputs.Templates, genConfig.TemplateDir, pkgConfig); err != nil {
+				return "", err
+			}
+		}
+
+		inputs.Generators = append(inputs.Generators, genConfig)
+	}
+
+	if err := hashBuiltinTemplates(inputs.Templates); err != nil {
+		return "", err
+	}
+
+	if wholeSchema {
+		inputs.Schema = schemaHash
+	} else {
+		used, err := schemaUsedByPackage(s, pkgConfig, expansion)
+		if err != nil {
+			return "", err
+		}
+
+		inputs.Schema = used
+	}
+
+	content, err := json.Marshal(inputs)
+	if err != nil {
+		return "", err
+	}
+
+	return hashBytes(content), nil
+}
+
+// schemaUsedByPackage returns the expanded types of the package, along with
+// the root fields matching its mutations and the types along its query paths.
+func schemaUsedByPackage(s *schema.Schema, pkgConfig *config.PackageConfig, expansion *schema.Expansion) (*schemaInputs, error) {
+	expandedTypes, err := expansion.Types(s, pkgConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	used := &schemaInputs{}
+
+	if expandedTypes != nil {
+		used.Types = append(used.Types, *expandedTypes...)
+		sort.SliceStable(used.Types, func(i, j int) bool {
+			return used.Types[i].Name < used.Types[j].Name
+		})
+	}
+
+	// Mutation names are patterns
+	for _, m := range pkgConfig.Mutations {
+		used.Mutations = append(used.Mutations, s.LookupMutationsByPattern(m.Name)...)
+	}
+
+	for _, q := range pkgConfig.Queries {
+		types, err := s.LookupQueryTypesByFieldPath(q.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		used.QueryPaths = append(used.QueryPaths, types)
+	}
+
+	return used, nil
+}
+
+// hashTemplateDir adds the hash of every file in the template directory,
+// which may itself be a template of the package name.  A directory that
+// doesn't exist is skipped, since the built-in templates are used instead.
+func hashTemplateDir(hashes map[string]string, templateDir string, pkgConfig *config.PackageConfig) error {
+	if strings.Contains(templateDir, "{{") {
+		dir, err := codegen.RenderTemplate("templateDir", templateDir, map[string]string{"PackageName": pkgConfig.Name})
+		if err != nil {
+			return errUncacheable
+		}
+
+		templateDir = dir
+	}
+
+	entries, err := ioutil.ReadDir(templateDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+
+		if err := hashFile(hashes, path.Join(templateDir, e.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// hashBuiltinTemplates adds the hash of every built-in template.
+func hashBuiltinTemplates(hashes map[string]string) error {
+	return fs.WalkDir(templates.FS, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		content, err := fs.ReadFile(templates.FS, p)
+		if err != nil {
+			return err
+		}
+
+		hashes[codegen.BuiltinTemplatePrefix+p] = hashBytes(content)
+
+		return nil
+	})
+}
+
+func hashFile(hashes map[string]string, file string) error {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	hashes[file] = hashBytes(content)
+
+	return nil
+}
+
+func hashBytes(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,132 @@
+//go:build unit
+// +build unit
+
+package generate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/tutone/internal/config"
+	"github.com/newrelic/tutone/internal/schema"
+)
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "tutone-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := cacheFile(path.Join(dir, "schema.json"))
+	assert.Equal(t, path.Join(dir, DefaultCacheFile), file)
+
+	c, err := LoadCache(file)
+	require.NoError(t, err)
+	assert.Empty(t, c.Packages)
+
+	c.Packages["alerts"] = "abc"
+	require.NoError(t, c.Save(file))
+
+	c, err = LoadCache(file)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"alerts": "abc"}, c.Packages)
+}
+
+func TestFingerprint(t *testing.T) {
+	t.Parallel()
+
+	s := &schema.Schema{
+		MutationType: &schema.Type{Name: "RootMutationType"},
+		QueryType:    &schema.Type{Name: "RootQueryType"},
+		Types: []*schema.Type{
+			{Name: "Alert", Kind: schema.KindObject, Fields: []schema.Field{{Name: "name", Type: schema.TypeRef{Name: "String", Kind: schema.KindScalar}}}},
+			{Name: "Other", Kind: schema.KindObject},
+			{Name: "String", Kind: schema.KindScalar},
+		},
+	}
+
+	cfg := &config.Config{
+		Generators: []config.GeneratorConfig{{Name: "typegen"}},
+	}
+
+	pkgConfig := &config.PackageConfig{
+		Name:       "alerts",
+		Generators: []string{"typegen"},
+		Types:      []config.TypeConfig{{Name: "Alert"}},
+	}
+
+	fp, err := fingerprint(s, "hash", cfg, pkgConfig, &schema.Expansion{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, fp)
+
+	same, err := fingerprint(s, "hash", cfg, pkgConfig, &schema.Expansion{})
+	require.NoError(t, err)
+	assert.Equal(t, fp, same)
+
+	// Types that the package doesn't use are ignored
+	s.Types[1].Description = "changed"
+	same, err = fingerprint(s, "hash", cfg, pkgConfig, &schema.Expansion{})
+	require.NoError(t, err)
+	assert.Equal(t, fp, same)
+
+	s.Types[0].Description = "changed"
+	changed, err := fingerprint(s, "hash", cfg, pkgConfig, &schema.Expansion{})
+	require.NoError(t, err)
+	assert.NotEqual(t, fp, changed)
+
+	pkgConfig.StrictEnums = true
+	changedConfig, err := fingerprint(s, "hash", cfg, pkgConfig, &schema.Expansion{})
+	require.NoError(t, err)
+	assert.NotEqual(t, changed, changedConfig)
+
+	cfg.Generators[0].TemplateURL = "https://example.com/types.go.tmpl"
+	_, err = fingerprint(s, "hash", cfg, pkgConfig, &schema.Expansion{})
+	assert.ErrorIs(t, err, errUncacheable)
+}
+
+func TestFingerprint_MutationPattern(t *testing.T) {
+	t.Parallel()
+
+	boolean := schema.TypeRef{Name: "Boolean", Kind: schema.KindScalar}
+	s := &schema.Schema{
+		MutationType: &schema.Type{Name: "RootMutationType", Fields: []schema.Field{
+			{Name: "alertsPolicyCreate", Type: boolean},
+			{Name: "alertsPolicyDelete", Type: boolean},
+			{Name: "tagCreate", Type: boolean},
+		}},
+		QueryType: &schema.Type{Name: "RootQueryType"},
+		Types: []*schema.Type{
+			{Name: "Boolean", Kind: schema.KindScalar},
+		},
+	}
+
+	cfg := &config.Config{
+		Generators: []config.GeneratorConfig{{Name: "nerdgraphclient"}},
+	}
+
+	pkgConfig := &config.PackageConfig{
+		Name:       "alerts",
+		Generators: []string{"nerdgraphclient"},
+		Mutations:  []config.MutationConfig{{Name: "alertsPolicy.*"}},
+	}
+
+	fp, err := fingerprint(s, "hash", cfg, pkgConfig, &schema.Expansion{})
+	require.NoError(t, err)
+
+	// Mutations that don't match the pattern are ignored
+	s.MutationType.Fields[2].Description = "changed"
+	same, err := fingerprint(s, "hash", cfg, pkgConfig, &schema.Expansion{})
+	require.NoError(t, err)
+	assert.Equal(t, fp, same)
+
+	s.MutationType.Fields[1].Description = "changed"
+	changed, err := fingerprint(s, "hash", cfg, pkgConfig, &schema.Expansion{})
+	require.NoError(t, err)
+	assert.NotEqual(t, fp, changed)
+}
@@ -1,6 +1,12 @@
 package generate
 
 import (
+	"context"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -16,6 +22,11 @@ const (
 var (
 	packageName string
 	refetch     bool
+	check       bool
+	diff        bool
+	jobs        int
+	force       bool
+	watch       bool
 )
 
 var Command = &cobra.Command{
@@ -28,13 +39,44 @@ configured types in your .tutone.yml configuration file.
 Use the --refetch flag when new types have been added to
 your upstream GraphQL schema to ensure your generated code
 is up to date with your configured GraphQL API.
+
+Use the --check flag to compare the generated code with the
+files on disk without writing them, exiting non-zero when
+they differ.  The --diff flag also prints a unified diff.
+
+Packages whose config, templates and schema types are unchanged
+since they were last generated are skipped.  Use the --force flag
+to generate every package.
+
+Use the --watch flag to generate again whenever the config
+file, templates or cached schema change.
 `,
 	Example: "tutone generate --config .tutone.yml",
 	Run: func(cmd *cobra.Command, args []string) {
-		util.LogIfError(log.ErrorLevel, Generate(GeneratorOptions{
+		options := GeneratorOptions{
 			PackageName: packageName,
 			Refetch:     refetch,
-		}))
+			Check:       check,
+			Diff:        diff,
+			Jobs:        jobs,
+			Force:       force,
+			Output:      cmd.OutOrStdout(),
+		}
+
+		if watch {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			if err := GenerateWatch(ctx, options); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+
+		// Exit non-zero so that failures, and drift when checking, fail CI.
+		if err := Generate(options); err != nil {
+			log.Fatal(err)
+		}
 	},
 }
 
@@ -48,4 +90,9 @@ func init() {
 	util.LogIfError(log.ErrorLevel, viper.BindPFlag("generate.type_file", Command.Flags().Lookup("types")))
 
 	Command.Flags().BoolVar(&refetch, "refetch", false, "Force a refetch of your GraphQL schema to ensure the generated types are up to date.")
+	Command.Flags().BoolVar(&check, "check", false, "Compare the generated code with the files on disk instead of writing, exiting non-zero when they differ")
+	Command.Flags().BoolVar(&diff, "diff", false, "Print a unified diff of the files that differ from the generated code, implies --check")
+	Command.Flags().IntVarP(&jobs, "jobs", "j", runtime.NumCPU(), "Number of generators to run at once")
+	Command.Flags().BoolVar(&force, "force", false, "Generate every package, ignoring the cache of unchanged packages")
+	Command.Flags().BoolVar(&watch, "watch", false, "Generate again whenever the config file, templates or cached schema change")
 }
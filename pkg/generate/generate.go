@@ -1,14 +1,23 @@
 package generate
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"runtime"
+	"strings"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 
 	"github.com/newrelic/tutone/generators/command"
 	"github.com/newrelic/tutone/generators/nerdgraphclient"
+	"github.com/newrelic/tutone/generators/plugin"
+	"github.com/newrelic/tutone/generators/terraform"
 	"github.com/newrelic/tutone/generators/typegen"
 	"github.com/newrelic/tutone/internal/codegen"
 	"github.com/newrelic/tutone/internal/config"
@@ -19,47 +28,237 @@ import (
 type GeneratorOptions struct {
 	PackageName string
 	Refetch     bool
+	// Force generates every package, rather than skipping the packages whose
+	// inputs are unchanged since they were last generated.
+	Force bool
+	// Jobs is the number of generators run at once, defaulting to the number of
+	// CPUs.
+	Jobs int
+	// SchemaFile is the cached schema, taking precedence over the schema_file
+	// of the config.
+	SchemaFile string
+	// Check compares the generated code with the files on disk, rather than
+	// writing it, returning an error when they differ.
+	Check bool
+	// Diff implies Check, and also prints a unified diff of each file.
+	Diff bool
+	// Output receives the results of a Check, defaulting to os.Stdout.
+	Output io.Writer
+}
+
+var (
+	// ErrNoPackages is returned when the config doesn't have any packages.
+	ErrNoPackages = errors.New("an array of packages is required")
+	// ErrPackageNotFound is returned when generating a package that isn't in
+	// the config.
+	ErrPackageNotFound = errors.New("package not found")
+)
+
+// Errors are the failures of several generators, in the order they are
+// configured.
+type Errors []error
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return fmt.Sprintf("%d generators failed:\n\t%s", len(e), strings.Join(messages, "\n\t"))
+}
+
+// Is determines if any of the errors matches the target, see errors.Is.
+func (e Errors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As finds the first of the errors that matches the target, see errors.As.
+func (e Errors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newErrors returns the errors that are not nil, as a single error when there
+// is only one.
+func newErrors(errs []error) error {
+	var result Errors
+	for _, err := range errs {
+		if err != nil {
+			result = append(result, err)
+		}
+	}
+
+	switch len(result) {
+	case 0:
+		return nil
+	case 1:
+		return result[0]
+	default:
+		return result
+	}
+}
+
+// OutOfDateError is returned by a check when the generated code differs from
+// the files on disk.
+type OutOfDateError struct {
+	Results []codegen.CheckResult
+}
+
+func (e *OutOfDateError) Error() string {
+	return fmt.Sprintf("generated code is out of date: %d file(s) differ", len(e.Results))
 }
 
 // Generate reads the configuration file and executes generators relevant to a particular package.
 func Generate(options GeneratorOptions) error {
-	schemaFile := viper.GetString("cache.schema_file")
+	cfg, _, err := loadConfig()
+	if err != nil {
+		return err
+	}
 
-	_, err := os.Stat(schemaFile)
+	return Run(context.Background(), cfg, withSchemaFile(options))
+}
 
-	// Fetch a new schema if it doesn't exist or if --refetch flag has been provided.
-	if os.IsNotExist(err) || options.Refetch {
-		fetch.Fetch(
-			viper.GetString("endpoint"),
-			viper.GetBool("auth.disable"),
-			viper.GetString("auth.header"),
-			viper.GetString("auth.api_key_env_var"),
-			schemaFile,
-			options.Refetch,
-		)
+// GenerateWatch is Generate, generating again whenever the configuration file,
+// templates or cached schema change, until the context is done.
+func GenerateWatch(ctx context.Context, options GeneratorOptions) error {
+	return Watch(ctx, loadConfig, withSchemaFile(options))
+}
+
+// withSchemaFile returns the options with the schema file set by a flag or
+// environment variable, which takes precedence over the schema_file of the
+// loaded config.
+func withSchemaFile(options GeneratorOptions) GeneratorOptions {
+	if options.SchemaFile == "" && overridden("cache.schema_file") {
+		options.SchemaFile = viper.GetString("cache.schema_file")
 	}
 
-	log.WithFields(log.Fields{
-		"schema_file": schemaFile,
-	}).Info("Loading generation config")
+	return options
+}
 
-	// load the config
-	cfg, err := config.LoadConfig(viper.ConfigFileUsed())
+// loadConfig reads the configuration file used by viper, returning the files
+// read along with those it includes and extends.
+func loadConfig() (*config.Config, []string, error) {
+	loader := &config.Loader{}
+
+	cfg, err := loader.Load(viper.ConfigFileUsed())
 	if err != nil {
-		return err
+		return nil, loader.Files, err
+	}
+
+	// Flags and environment variables take precedence over the config file.
+	if overridden("endpoint") {
+		cfg.Endpoint = viper.GetString("endpoint")
+	}
+
+	if overridden("auth.disable") {
+		cfg.Auth.Disable = viper.GetBool("auth.disable")
+	}
+
+	if overridden("auth.header") {
+		cfg.Auth.Header = viper.GetString("auth.header")
+	}
+
+	if overridden("auth.api_key_env_var") {
+		cfg.Auth.EnvVar = viper.GetString("auth.api_key_env_var")
+	}
+
+	return cfg, loader.Files, nil
+}
+
+// overridden determines if the key is set by a flag or environment variable.
+// The values viper reads from the config file itself are ignored, since they
+// are missing the included and extended files and the interpolation.
+func overridden(key string) bool {
+	if !viper.InConfig(key) {
+		return viper.IsSet(key)
+	}
+
+	_, ok := os.LookupEnv("TUTONE_" + strings.ToUpper(key))
+
+	return ok
+}
+
+// Run executes the generators of the configured packages, or only the package
+// named in the options.  The schema is fetched when it isn't cached, or when
+// a refetch is requested.  Runs may be concurrent, as long as they don't share
+// a manifest, cache or output directory.
+func Run(ctx context.Context, cfg *config.Config, options GeneratorOptions) error {
+	_, err := run(ctx, cfg, options)
+	return err
+}
+
+// runResult is the packages generated, and skipped as unchanged, by a run.
+type runResult struct {
+	generated []string
+	skipped   []string
+}
+
+// schemaFileFor returns the cached schema used by the options.
+func schemaFileFor(cfg *config.Config, options GeneratorOptions) string {
+	if options.SchemaFile != "" {
+		return options.SchemaFile
+	}
+
+	if cfg.Cache.SchemaFile != "" {
+		return cfg.Cache.SchemaFile
+	}
+
+	return fetch.DefaultSchemaCacheFile
+}
+
+func run(ctx context.Context, cfg *config.Config, options GeneratorOptions) (*runResult, error) {
+	if cfg == nil {
+		return nil, errors.New("config is required")
 	}
 
 	log.Debugf("config: %+v", cfg)
 
 	// package is required
 	if len(cfg.Packages) == 0 {
-		return fmt.Errorf("an array of packages is required")
+		return nil, ErrNoPackages
 	}
 
+	schemaFile := schemaFileFor(cfg, options)
+
+	_, err := os.Stat(schemaFile)
+
+	// Fetch a new schema if it doesn't exist or if --refetch flag has been provided.
+	if os.IsNotExist(err) || options.Refetch {
+		authHeader := cfg.Auth.Header
+		if authHeader == "" {
+			authHeader = fetch.DefaultAuthHeader
+		}
+
+		authEnvVar := cfg.Auth.EnvVar
+		if authEnvVar == "" {
+			authEnvVar = fetch.DefaultAPIKeyEnv
+		}
+
+		err = fetch.Fetch(ctx, cfg.Endpoint, cfg.Auth.Disable, authHeader, authEnvVar, schemaFile, options.Refetch)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"schema_file": schemaFile,
+	}).Info("Loading schema")
+
 	// Load the schema
 	s, err := schema.Load(schemaFile)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	log.WithFields(log.Fields{
@@ -70,19 +269,166 @@ func Generate(options GeneratorOptions) error {
 		// "count_subscription": len(cfg.Subscriptions),
 	}).Info("starting code generation")
 
-	// Generate for a specific package
-	if options.PackageName != "" {
-		return generateForPackage(options.PackageName, cfg, s)
+	manifestFile := cfg.Manifest
+	if manifestFile == "" {
+		manifestFile = codegen.DefaultManifestFile
+	}
+
+	manifest, err := codegen.LoadManifest(manifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest %s: %s", manifestFile, err)
+	}
+
+	var check *codegen.Check
+	if options.Check || options.Diff {
+		check = &codegen.Check{}
 	}
 
-	// Generate for all configured packages
+	output := codegen.Output{Manifest: manifest, Check: check}
+
+	var configuredPackages []string
 	for _, pkgConfig := range cfg.Packages {
-		if err := generateForPackage(pkgConfig.Name, cfg, s); err != nil {
-			return err
+		configuredPackages = append(configuredPackages, pkgConfig.Name)
+	}
+
+	// Generate for a specific package, or all configured packages
+	generatedPackages := configuredPackages
+	if options.PackageName != "" {
+		generatedPackages = []string{options.PackageName}
+	}
+
+	pkgConfigs := make([]*config.PackageConfig, len(generatedPackages))
+	expansions := make([]*schema.Expansion, len(generatedPackages))
+	for i, packageName := range generatedPackages {
+		pkgConfigs[i], err = findPackageConfig(packageName, cfg)
+		if err != nil {
+			return nil, err
 		}
+
+		expansions[i] = &schema.Expansion{}
 	}
 
-	return nil
+	// The cache is bypassed by a check, which compares every generated file.
+	// Forcing still records the fingerprints for the next run.
+	var cache *Cache
+	fingerprints := make([]string, len(generatedPackages))
+	if check == nil {
+		cache, err = LoadCache(cacheFile(schemaFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load cache %s: %s", cacheFile(schemaFile), err)
+		}
+
+		fingerprints, err = packageFingerprints(ctx, s, schemaFile, cfg, pkgConfigs, expansions, options.Jobs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := &runResult{}
+
+	// The schema is only loaded for validation once, by the first package.
+	validator := &schema.SharedValidator{}
+
+	var tasks []task
+	for i, pkgConfig := range pkgConfigs {
+		if cache != nil && !options.Force && fingerprints[i] != "" && cache.Packages[pkgConfig.Name] == fingerprints[i] && manifest.PackageUnchanged(pkgConfig.Name) {
+			log.WithFields(log.Fields{
+				"name": pkgConfig.Name,
+			}).Info("package is unchanged, skipping")
+
+			result.skipped = append(result.skipped, pkgConfig.Name)
+			continue
+		}
+
+		tasks = append(tasks, packageTasks(pkgConfig, cfg, expansions[i], validator, output)...)
+		result.generated = append(result.generated, pkgConfig.Name)
+	}
+
+	if err := runTasks(ctx, s, tasks, options.Jobs); err != nil {
+		return nil, err
+	}
+
+	// Remove the files that were previously generated for the packages, but
+	// no longer are.
+	if err := manifest.Prune(result.generated, configuredPackages, check); err != nil {
+		return nil, err
+	}
+
+	if check != nil {
+		return result, reportCheck(check, options)
+	}
+
+	if err := manifest.Save(manifestFile); err != nil {
+		return nil, err
+	}
+
+	for i, pkgConfig := range pkgConfigs {
+		if fingerprints[i] == "" {
+			delete(cache.Packages, pkgConfig.Name)
+		} else {
+			cache.Packages[pkgConfig.Name] = fingerprints[i]
+		}
+	}
+
+	return result, cache.Save(cacheFile(schemaFile))
+}
+
+// packageFingerprints returns the fingerprint of each package, or an empty
+// string for the packages that can't be cached.
+func packageFingerprints(ctx context.Context, s *schema.Schema, schemaFile string, cfg *config.Config, pkgConfigs []*config.PackageConfig, expansions []*schema.Expansion, jobs int) ([]string, error) {
+	content, err := ioutil.ReadFile(schemaFile)
+	if err != nil {
+		return nil, err
+	}
+
+	schemaHash := hashBytes(content)
+
+	fingerprints := make([]string, len(pkgConfigs))
+	err = parallel(ctx, len(pkgConfigs), jobs, func(i int) error {
+		fp, err := fingerprint(s, schemaHash, cfg, pkgConfigs[i], expansions[i])
+		if err != nil {
+			log.WithFields(log.Fields{
+				"name": pkgConfigs[i].Name,
+			}).Debugf("package will be generated: %s", err)
+
+			return nil
+		}
+
+		fingerprints[i] = fp
+
+		return nil
+	})
+
+	return fingerprints, err
+}
+
+// reportCheck prints the files that differ from the generated code, returning
+// an error when there are any.
+func reportCheck(check *codegen.Check, options GeneratorOptions) error {
+	out := options.Output
+	if out == nil {
+		out = os.Stdout
+	}
+
+	results := check.Results()
+	if len(results) == 0 {
+		log.Info("generated code is up to date")
+		return nil
+	}
+
+	for _, r := range results {
+		fmt.Fprintf(out, "%s: %s\n", r.Status, r.Path)
+	}
+
+	if options.Diff {
+		for _, r := range results {
+			if r.Diff != "" {
+				fmt.Fprintf(out, "\n%s", r.Diff)
+			}
+		}
+	}
+
+	return &OutOfDateError{Results: results}
 }
 
 func findPackageConfigByName(name string, packages []config.PackageConfig) *config.PackageConfig {
@@ -95,12 +441,23 @@ func findPackageConfigByName(name string, packages []config.PackageConfig) *conf
 	return nil
 }
 
-func generatePkgTypes(pkgConfig *config.PackageConfig, cfg *config.Config, s *schema.Schema) error {
+// task is a single generator of a package.
+type task struct {
+	generatorName string
+	generator     codegen.Generator
+	genConfig     *config.GeneratorConfig
+	pkgConfig     *config.PackageConfig
+}
+
+// packageTasks returns the generators of the package, which share a single
+// expansion of the package types, and the validator and output of every
+// package.
+func packageTasks(pkgConfig *config.PackageConfig, cfg *config.Config, expansion *schema.Expansion, validator *schema.SharedValidator, output codegen.Output) []task {
 	allGenerators := map[string]codegen.Generator{
-		// &terraform.Generator{},
-		"typegen":         &typegen.Generator{},
-		"nerdgraphclient": &nerdgraphclient.Generator{},
-		"command":         &command.Generator{},
+		"typegen":         &typegen.Generator{Expansion: expansion, Output: output},
+		"nerdgraphclient": &nerdgraphclient.Generator{Expansion: expansion, Validator: validator, Output: output},
+		"command":         &command.Generator{Output: output},
+		"terraform":       &terraform.Generator{Output: output},
 	}
 
 	log.WithFields(log.Fields{
@@ -110,49 +467,123 @@ func generatePkgTypes(pkgConfig *config.PackageConfig, cfg *config.Config, s *sc
 		"count_imports": len(pkgConfig.Imports),
 	}).Info("generating package")
 
+	var tasks []task
+
 	for _, generatorName := range pkgConfig.Generators {
-		ggg, err := getGeneratorByName(generatorName, allGenerators)
+		genConfig, err := getGeneratorConfigByName(generatorName, cfg.Generators)
 		if err != nil {
 			log.Error(err)
 			continue
 		}
 
-		genConfig, err := getGeneratorConfigByName(generatorName, cfg.Generators)
-		if err != nil {
-			log.Error(err)
-			continue
+		// Plugins take the place of a built-in generator
+		var ggg *codegen.Generator
+		if genConfig.Plugin != "" {
+			var g codegen.Generator = &plugin.Generator{Output: output}
+			ggg = &g
+		} else {
+			ggg, err = getGeneratorByName(generatorName, allGenerators)
+			if err != nil {
+				log.Error(err)
+				continue
+			}
 		}
 
-		if ggg != nil && genConfig != nil {
-			g := *ggg
+		tasks = append(tasks, task{
+			generatorName: generatorName,
+			generator:     *ggg,
+			genConfig:     genConfig,
+			pkgConfig:     pkgConfig,
+		})
+	}
 
-			log.WithFields(log.Fields{
-				"generator": generatorName,
-			}).Info("starting generator")
+	return tasks
+}
 
-			err = g.Generate(s, genConfig, pkgConfig)
-			if err != nil {
-				return fmt.Errorf("failed to call Generate() for provider %T: %s", generatorName, err)
-			}
+func (t task) run(s *schema.Schema) error {
+	log.WithFields(log.Fields{
+		"generator": t.generatorName,
+		"package":   t.pkgConfig.Name,
+	}).Info("starting generator")
 
-			err = g.Execute(genConfig, pkgConfig)
-			if err != nil {
-				return fmt.Errorf("failed to call Execute() for provider %T: %s", generatorName, err)
-			}
-		}
+	if err := t.generator.Generate(s, t.genConfig, t.pkgConfig); err != nil {
+		return fmt.Errorf("failed to call Generate() for generator %s of package %s: %w", t.generatorName, t.pkgConfig.Name, err)
+	}
+
+	if err := t.generator.Execute(t.genConfig, t.pkgConfig); err != nil {
+		return fmt.Errorf("failed to call Execute() for generator %s of package %s: %w", t.generatorName, t.pkgConfig.Name, err)
 	}
 
 	return nil
 }
 
-func generateForPackage(packageName string, cfg *config.Config, schema *schema.Schema) error {
+// runTasks runs the tasks with at most jobs at a time.  Every task is run,
+// even when others fail, and the errors are returned in the order of the
+// tasks.
+func runTasks(ctx context.Context, s *schema.Schema, tasks []task, jobs int) error {
+	return parallel(ctx, len(tasks), jobs, func(i int) error {
+		return tasks[i].run(s)
+	})
+}
+
+// parallel calls fn for each of the n indexes, with at most jobs at a time,
+// defaulting to the number of CPUs.  The errors are returned in the order of
+// the indexes.
+func parallel(ctx context.Context, n int, jobs int, fn func(i int) error) error {
+	if jobs < 1 {
+		jobs = runtime.NumCPU()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	errs := make([]error, n+1)
+	sem := make(chan struct{}, jobs)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+
+		// Calls that have started are left to finish
+		if err := ctx.Err(); err != nil {
+			errs[n] = err
+			break
+		}
+
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			errs[i] = fn(i)
+		}(i)
+	}
+
+	wg.Wait()
+
+	return newErrors(errs)
+}
+
+// findPackageConfig returns the config of the package, with the global
+// scalars.
+func findPackageConfig(packageName string, cfg *config.Config) (*config.PackageConfig, error) {
 	pkg := findPackageConfigByName(packageName, cfg.Packages)
 
 	if pkg == nil {
-		return fmt.Errorf("[Error] package %v not found", packageName)
+		return nil, fmt.Errorf("%w: %s", ErrPackageNotFound, packageName)
 	}
 
-	return generatePkgTypes(pkg, cfg, schema)
+	// The package scalars are found first, and so take precedence over the
+	// global scalars.
+	pkg.Scalars = append(pkg.Scalars, cfg.Scalars...)
+
+	return pkg, nil
 }
 
 // getGeneratorConfigByName retrieve the *config.GeneratorConfig from the given set or errros.
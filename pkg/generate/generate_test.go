@@ -0,0 +1,211 @@
+//go:build unit
+// +build unit
+
+package generate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync/atomic"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/tutone/internal/config"
+	"github.com/newrelic/tutone/internal/schema"
+)
+
+func TestRun_Errors(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "tutone-generate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	schemaFile := path.Join(dir, "schema.json")
+	require.NoError(t, ioutil.WriteFile(schemaFile, []byte(`{"types": []}`), 0644))
+
+	err = Run(context.Background(), &config.Config{}, GeneratorOptions{})
+	assert.ErrorIs(t, err, ErrNoPackages)
+
+	cfg := &config.Config{
+		Cache:    config.CacheConfig{SchemaFile: schemaFile},
+		Packages: []config.PackageConfig{{Name: "alerts"}},
+		Manifest: path.Join(dir, "manifest.json"),
+	}
+
+	err = Run(context.Background(), cfg, GeneratorOptions{PackageName: "missing"})
+	assert.ErrorIs(t, err, ErrPackageNotFound)
+	assert.EqualError(t, err, "package not found: missing")
+
+	// The schema is fetched when it isn't cached, returning the failure
+	cfg.Endpoint = "http://127.0.0.1:0/graphql"
+	err = Run(context.Background(), cfg, GeneratorOptions{SchemaFile: path.Join(dir, "missing.json")})
+	assert.Error(t, err)
+	assert.NoFileExists(t, path.Join(dir, "missing.json"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = Run(ctx, cfg, GeneratorOptions{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// Runs with their own manifest and output don't affect each other, even when
+// only one of them is a check.
+func TestRun_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "tutone-generate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	schemaFile := path.Join(dir, "schema.json")
+	require.NoError(t, ioutil.WriteFile(schemaFile, []byte(`{
+		"mutationType": {"name": "RootMutationType"},
+		"queryType": {"name": "RootQueryType"},
+		"types": [
+			{"name": "RootMutationType", "kind": "OBJECT"},
+			{"name": "RootQueryType", "kind": "OBJECT"},
+			{"name": "Alert", "kind": "OBJECT", "fields": [{"name": "name", "type": {"name": "String", "kind": "SCALAR"}}]},
+			{"name": "String", "kind": "SCALAR"}
+		]
+	}`), 0644))
+
+	newConfig := func(name string) *config.Config {
+		return &config.Config{
+			Cache:      config.CacheConfig{SchemaFile: schemaFile},
+			Manifest:   path.Join(dir, name+".json"),
+			Generators: []config.GeneratorConfig{{Name: "typegen", FileName: "types.go"}},
+			Packages: []config.PackageConfig{{
+				Name:       name,
+				Path:       path.Join(dir, name),
+				Generators: []string{"typegen"},
+				Types:      []config.TypeConfig{{Name: "Alert"}},
+			}},
+		}
+	}
+
+	errs := make(chan error, 2)
+	go func() { errs <- Run(context.Background(), newConfig("written"), GeneratorOptions{Force: true}) }()
+	go func() { errs <- Run(context.Background(), newConfig("checked"), GeneratorOptions{Check: true}) }()
+
+	var outOfDate int
+	for i := 0; i < 2; i++ {
+		var e *OutOfDateError
+		if err := <-errs; errors.As(err, &e) {
+			outOfDate++
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+
+	assert.Equal(t, 1, outOfDate)
+	assert.FileExists(t, path.Join(dir, "written", "types.go"))
+	assert.NoFileExists(t, path.Join(dir, "checked", "types.go"))
+}
+
+// The schema file of an extended config is used, unless a flag or environment
+// variable overrides it.  Not parallel, since viper is global.
+func TestWithSchemaFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tutone-generate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.Setenv("TUTONE_TEST_SCHEMA_DIR", dir))
+	defer os.Unsetenv("TUTONE_TEST_SCHEMA_DIR")
+
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "base.yml"), []byte("cache:\n  schema_file: ${TUTONE_TEST_SCHEMA_DIR}/myschema.json\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, ".tutone.yml"), []byte("extends: base.yml\npackages:\n  - name: alerts\n"), 0644))
+
+	// The default of the flag is not an override
+	cmd := &cobra.Command{}
+	cmd.Flags().StringP("schema", "s", "schema.json", "")
+
+	defer viper.Reset()
+	require.NoError(t, viper.BindPFlag("cache.schema_file", cmd.Flags().Lookup("schema")))
+	viper.SetConfigFile(path.Join(dir, ".tutone.yml"))
+	require.NoError(t, viper.ReadInConfig())
+
+	cfg, _, err := loadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, path.Join(dir, "myschema.json"), schemaFileFor(cfg, withSchemaFile(GeneratorOptions{})))
+
+	require.NoError(t, cmd.Flags().Set("schema", "other.json"))
+	assert.Equal(t, "other.json", schemaFileFor(cfg, withSchemaFile(GeneratorOptions{})))
+	assert.Equal(t, "option.json", schemaFileFor(cfg, withSchemaFile(GeneratorOptions{SchemaFile: "option.json"})))
+}
+
+// fakeGenerator fails when fail is set, and records the most generators
+// running at once.
+type fakeGenerator struct {
+	fail    bool
+	running *int32
+	most    *int32
+}
+
+func (g *fakeGenerator) Generate(s *schema.Schema, genConfig *config.GeneratorConfig, pkgConfig *config.PackageConfig) error {
+	n := atomic.AddInt32(g.running, 1)
+	defer atomic.AddInt32(g.running, -1)
+
+	for {
+		most := atomic.LoadInt32(g.most)
+		if n <= most || atomic.CompareAndSwapInt32(g.most, most, n) {
+			break
+		}
+	}
+
+	if g.fail {
+		return errors.New("failed")
+	}
+
+	return nil
+}
+
+func (g *fakeGenerator) Execute(genConfig *config.GeneratorConfig, pkgConfig *config.PackageConfig) error {
+	return nil
+}
+
+func TestRunTasks(t *testing.T) {
+	t.Parallel()
+
+	var running, most int32
+
+	var tasks []task
+	for i := 0; i < 20; i++ {
+		tasks = append(tasks, task{
+			generatorName: "fake",
+			generator:     &fakeGenerator{fail: i%5 == 4, running: &running, most: &most},
+			genConfig:     &config.GeneratorConfig{Name: "fake"},
+			pkgConfig:     &config.PackageConfig{Name: fmt.Sprintf("pkg%d", i)},
+		})
+	}
+
+	err := runTasks(context.Background(), &schema.Schema{}, tasks, 3)
+	require.Error(t, err)
+	assert.LessOrEqual(t, most, int32(3))
+
+	var errs Errors
+	require.True(t, errors.As(err, &errs))
+	require.Len(t, errs, 4)
+
+	// The errors are in the order of the tasks
+	for i, name := range []string{"pkg4", "pkg9", "pkg14", "pkg19"} {
+		assert.EqualError(t, errs[i], "failed to call Generate() for generator fake of package "+name+": failed")
+	}
+
+	// A single failure is returned as is
+	most = 0
+	err = runTasks(context.Background(), &schema.Schema{}, tasks[:5], 1)
+	assert.EqualError(t, err, "failed to call Generate() for generator fake of package pkg4: failed")
+	assert.Equal(t, int32(1), most)
+
+	assert.NoError(t, runTasks(context.Background(), &schema.Schema{}, tasks[:4], 0))
+}
@@ -0,0 +1,244 @@
+package generate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/newrelic/tutone/internal/codegen"
+	"github.com/newrelic/tutone/internal/config"
+	"github.com/newrelic/tutone/internal/output"
+)
+
+// DefaultWatchDelay is how long Watch waits after a change before generating,
+// so that several changes, such as saving many templates, are generated
+// together.
+const DefaultWatchDelay = 300 * time.Millisecond
+
+// Watch generates the packages, and generates them again whenever the
+// configuration files, the template directories or the cached schema change,
+// until the context is done.  The configuration is loaded again before each
+// generation, and only the packages whose inputs changed are generated, see
+// Cache.  The result of each generation, including any failure, is printed to
+// the Output of the options rather than returned.
+func Watch(ctx context.Context, load func() (*config.Config, []string, error), options GeneratorOptions) error {
+	if options.Check || options.Diff {
+		return errors.New("watching can't be combined with a check")
+	}
+
+	out := options.Output
+	if out == nil {
+		out = os.Stdout
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	// A summary of each generation is printed instead of every file.
+	output.SetQuiet(true)
+	defer output.SetQuiet(false)
+
+	w := newWatched(watcher)
+
+	generate := func() {
+		start := time.Now()
+
+		cfg, configFiles, err := load()
+		if err != nil {
+			fmt.Fprintf(out, "%s failed to load config: %s\n", start.Format("15:04:05"), err)
+			w.update(configFiles, nil, options)
+			return
+		}
+
+		w.update(configFiles, cfg, options)
+
+		result, err := run(ctx, cfg, options)
+		printWatchResult(out, start, result, err)
+
+		// Only the first generation is refetched or forced.
+		options.Refetch = false
+		options.Force = false
+	}
+
+	generate()
+
+	var delay <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			if w.matches(event) {
+				log.WithFields(log.Fields{
+					"file": event.Name,
+					"op":   event.Op.String(),
+				}).Debug("change detected")
+
+				delay = time.After(DefaultWatchDelay)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+
+			fmt.Fprintf(out, "%s watch error: %s\n", time.Now().Format("15:04:05"), err)
+		case <-delay:
+			delay = nil
+			generate()
+		}
+	}
+}
+
+// printWatchResult prints a single line summarizing a generation.
+func printWatchResult(out io.Writer, start time.Time, result *runResult, err error) {
+	prefix := start.Format("15:04:05")
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	if err != nil {
+		fmt.Fprintf(out, "%s generation failed after %s: %s\n", prefix, elapsed, err)
+		return
+	}
+
+	if len(result.generated) == 0 {
+		fmt.Fprintf(out, "%s no changes (%d unchanged)\n", prefix, len(result.skipped))
+		return
+	}
+
+	fmt.Fprintf(out, "%s generated %s (%d unchanged) in %s\n", prefix, strings.Join(result.generated, ", "), len(result.skipped), elapsed)
+}
+
+// watched are the files and directories whose changes trigger a generation.
+type watched struct {
+	watcher *fsnotify.Watcher
+	// files are the configuration file and cached schema, while any file in
+	// the template dirs is matched.
+	files        map[string]bool
+	templateDirs map[string]bool
+	// dirs are the directories added to the watcher, since files that are
+	// replaced rather than written, as many editors do, aren't watched
+	// otherwise.
+	dirs map[string]bool
+}
+
+func newWatched(watcher *fsnotify.Watcher) *watched {
+	return &watched{
+		watcher:      watcher,
+		files:        map[string]bool{},
+		templateDirs: map[string]bool{},
+		dirs:         map[string]bool{},
+	}
+}
+
+// update watches the configuration files, and the cached schema and template
+// directories of the configuration, when it could be loaded.
+func (w *watched) update(configFiles []string, cfg *config.Config, options GeneratorOptions) {
+	files := map[string]bool{}
+	for _, f := range configFiles {
+		files[absPath(f)] = true
+	}
+
+	templateDirs := map[string]bool{}
+
+	if cfg != nil {
+		files[absPath(schemaFileFor(cfg, options))] = true
+
+		for _, pkgConfig := range cfg.Packages {
+			for _, generatorName := range pkgConfig.Generators {
+				genConfig, err := getGeneratorConfigByName(generatorName, cfg.Generators)
+				if err != nil || genConfig.TemplateDir == "" {
+					continue
+				}
+
+				templateDir := genConfig.TemplateDir
+				if strings.Contains(templateDir, "{{") {
+					templateDir, err = codegen.RenderTemplate("templateDir", templateDir, map[string]string{"PackageName": pkgConfig.Name})
+					if err != nil {
+						continue
+					}
+				}
+
+				templateDirs[absPath(templateDir)] = true
+			}
+		}
+	} else {
+		// Keep watching the previous configuration until it can be loaded.
+		for f := range w.files {
+			files[f] = true
+		}
+
+		templateDirs = w.templateDirs
+	}
+
+	dirs := map[string]bool{}
+	for f := range files {
+		dirs[filepath.Dir(f)] = true
+	}
+
+	for d := range templateDirs {
+		dirs[d] = true
+	}
+
+	for d := range w.dirs {
+		if !dirs[d] {
+			if err := w.watcher.Remove(d); err != nil {
+				log.WithFields(log.Fields{
+					"dir": d,
+				}).Debugf("unable to stop watching: %s", err)
+			}
+		}
+	}
+
+	for d := range dirs {
+		if w.dirs[d] {
+			continue
+		}
+
+		if err := w.watcher.Add(d); err != nil {
+			log.WithFields(log.Fields{
+				"dir": d,
+			}).Debugf("unable to watch: %s", err)
+
+			delete(dirs, d)
+		}
+	}
+
+	w.files = files
+	w.templateDirs = templateDirs
+	w.dirs = dirs
+}
+
+// matches determines if the event is a change to a watched file.
+func (w *watched) matches(event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+
+	name := absPath(event.Name)
+
+	return w.files[name] || w.templateDirs[filepath.Dir(name)]
+}
+
+func absPath(file string) string {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return filepath.Clean(file)
+	}
+
+	return abs
+}
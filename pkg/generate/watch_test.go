@@ -0,0 +1,61 @@
+//go:build unit
+// +build unit
+
+package generate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/tutone/internal/config"
+)
+
+func TestWatched(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "tutone-watch")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	templateDir := path.Join(dir, "templates", "alerts")
+	require.NoError(t, os.MkdirAll(templateDir, 0755))
+
+	watcher, err := fsnotify.NewWatcher()
+	require.NoError(t, err)
+	defer watcher.Close()
+
+	w := newWatched(watcher)
+
+	configFile := path.Join(dir, ".tutone.yml")
+	cfg := &config.Config{
+		Cache:      config.CacheConfig{SchemaFile: path.Join(dir, "schema.json")},
+		Packages:   []config.PackageConfig{{Name: "alerts", Generators: []string{"typegen"}}},
+		Generators: []config.GeneratorConfig{{Name: "typegen", TemplateDir: path.Join(dir, "templates", "{{.PackageName}}")}},
+	}
+
+	w.update([]string{configFile}, cfg, GeneratorOptions{})
+	assert.Equal(t, map[string]bool{dir: true, templateDir: true}, w.dirs)
+
+	assert.True(t, w.matches(fsnotify.Event{Name: configFile, Op: fsnotify.Write}))
+	assert.True(t, w.matches(fsnotify.Event{Name: path.Join(dir, "schema.json"), Op: fsnotify.Create}))
+	assert.True(t, w.matches(fsnotify.Event{Name: path.Join(templateDir, "types.go.tmpl"), Op: fsnotify.Write}))
+	assert.False(t, w.matches(fsnotify.Event{Name: configFile, Op: fsnotify.Chmod}))
+	assert.False(t, w.matches(fsnotify.Event{Name: path.Join(dir, DefaultCacheFile), Op: fsnotify.Write}))
+
+	// The previous files are watched until the config can be loaded
+	includedFile := path.Join(dir, "alerts", ".tutone.yml")
+	w.update([]string{configFile, includedFile}, nil, GeneratorOptions{})
+	assert.True(t, w.matches(fsnotify.Event{Name: path.Join(templateDir, "types.go.tmpl"), Op: fsnotify.Write}))
+	assert.True(t, w.matches(fsnotify.Event{Name: includedFile, Op: fsnotify.Write}))
+
+	cfg.Generators[0].TemplateDir = ""
+	w.update([]string{configFile}, cfg, GeneratorOptions{})
+	assert.Equal(t, map[string]bool{dir: true}, w.dirs)
+	assert.False(t, w.matches(fsnotify.Event{Name: path.Join(templateDir, "types.go.tmpl"), Op: fsnotify.Write}))
+}
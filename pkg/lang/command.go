@@ -40,6 +40,9 @@ type CommandFlag struct {
 	Required       bool
 	IsInputType    bool
 	IsEnumType     bool
+	// EnumValues are the values accepted by an enum flag, used for validation
+	// and shell completion.
+	EnumValues []string
 }
 
 type CommandExampleData struct {
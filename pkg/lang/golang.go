@@ -22,6 +22,8 @@ type GolangGenerator struct {
 	Interfaces  []GoInterface
 	Mutations   []GoMethod
 	Queries     []GoMethod
+	Selections  []GoSelection
+	Fragments   []GoFragment
 }
 
 type GoStruct struct {
@@ -32,6 +34,17 @@ type GoStruct struct {
 	Implements       []string
 	SpecialUnmarshal bool
 	GenerateGetters  bool
+	// IsInputObject is set for the structs of GraphQL input objects.
+	IsInputObject bool
+	// Validate signals the template to render a Validate method, using the
+	// Validation of each field.  Every input object has one when inputs are
+	// validated, even without any checks, since the input objects and methods
+	// containing it call it.
+	Validate bool
+	// NullableFields are the names of the fields which may be listed in the
+	// NullFields of the struct, sent as null to clear their value.  Empty when
+	// explicit nulls are not enabled.
+	NullableFields []string
 }
 
 type GoStructField struct {
@@ -43,23 +56,51 @@ type GoStructField struct {
 	Description string
 	IsInterface bool
 	IsList      bool
+	// Validation is the set of checks made on the field by the Validate method
+	// of an input object, nil when there is nothing to check.
+	Validation *GoFieldValidation
+}
+
+// GoFieldValidation describes the checks made on a single field of an input
+// object, derived from the schema.
+type GoFieldValidation struct {
+	// Zero is the zero value of a non-null field, used to detect a missing value.
+	// Empty when a missing value can not be distinguished from the zero value.
+	Zero string
+	// Enum checks that the value is one of the values of the enum type.
+	Enum bool
+	// Nested calls the Validate method of the input object.
+	Nested bool
 }
 
 type GoEnum struct {
 	Name        string
 	Description string
 	Values      []GoEnumValue
+	// Strict signals the template to render an UnmarshalJSON that rejects
+	// unknown values.
+	Strict bool
 }
 
 type GoEnumValue struct {
 	Name        string
 	Description string
+	// Deprecated is the reason the value is deprecated, empty when the value is
+	// not deprecated.
+	Deprecated string
 }
 
 type GoScalar struct {
 	Name        string
 	Description string
 	Type        string
+	// Alias renders the scalar as an alias of the Type, so that any methods of
+	// the Type, such as MarshalJSON, are retained.
+	Alias bool
+	// Marshaler is the name of the function used to encode the scalar as JSON.
+	Marshaler string
+	// Unmarshaler is the name of the function used to decode the scalar from JSON.
+	Unmarshaler string
 }
 
 type GoInterface struct {
@@ -83,6 +124,23 @@ type GoMethod struct {
 	QueryString string
 	// ResponseObjectType is the name of the type for the API response.  Note that this is not the method return, but the API call response.
 	ResponseObjectType string
+	// SelectionType is the name of the selection builder for the method's
+	// return type, set only when selection builders are enabled for the package.
+	SelectionType string
+	// SelectionQueryString is the QueryString with the selection set replaced
+	// by the SelectionQueryPlaceholder.
+	SelectionQueryString string
+	// Fragments are the names of the GoFragment constants that must be sent
+	// along with the QueryString.
+	Fragments []string
+}
+
+// GoFragment is a GraphQL fragment that is shared by the methods of a package.
+type GoFragment struct {
+	// Name is the name of the Go constant holding the fragment definition.
+	Name        string
+	GraphQLName string
+	Definition  string
 }
 
 type GoMethodSignature struct {
@@ -97,6 +155,10 @@ type GoMethodSignature struct {
 type GoMethodInputType struct {
 	Name string
 	Type string
+	// Validate signals the template to call the Validate method of the input
+	// before the request is sent.
+	Validate bool
+	IsList   bool
 }
 
 type QueryVar struct {
@@ -106,7 +168,8 @@ type QueryVar struct {
 }
 
 // GenerateGoMethodQueriesForPackage uses the provided configuration to generate the GoMethod structs that contain the information about performing GraphQL queries.
-func GenerateGoMethodQueriesForPackage(s *schema.Schema, genConfig *config.GeneratorConfig, pkgConfig *config.PackageConfig) (*[]GoMethod, error) {
+// When a FragmentSet is received, nested selections are added to it and spread into the query strings.
+func GenerateGoMethodQueriesForPackage(s *schema.Schema, genConfig *config.GeneratorConfig, pkgConfig *config.PackageConfig, fragments *schema.FragmentSet) (*[]GoMethod, error) {
 	var methods []GoMethod
 
 	for _, pkgQuery := range pkgConfig.Queries {
@@ -135,10 +198,22 @@ func GenerateGoMethodQueriesForPackage(s *schema.Schema, genConfig *config.Gener
 				if field.Name == endpoint.Name {
 					method := goMethodForField(field, pkgConfig, inputFields)
 
-					method.QueryString = s.GetQueryStringForEndpoint(typePath, pkgQuery.Path, endpoint)
+					if fragments != nil {
+						var methodFragments []*schema.Fragment
+						method.QueryString, methodFragments = s.GetQueryStringForEndpointWithFragments(typePath, pkgQuery.Path, endpoint, fragments)
+						method.Fragments = goFragmentNames(methodFragments)
+					} else {
+						method.QueryString = s.GetQueryStringForEndpoint(typePath, pkgQuery.Path, endpoint)
+					}
+
 					method.ResponseObjectType = fmt.Sprintf("%sResponse", endpoint.Name)
 					method.Signature.ReturnPath = returnPath
 
+					method.SelectionType = selectionTypeForField(s, pkgConfig, field)
+					if method.SelectionType != "" {
+						method.SelectionQueryString = s.GetQueryStringForEndpointWithSelection(typePath, pkgQuery.Path, endpoint, SelectionQueryPlaceholder)
+					}
+
 					methods = append(methods, method)
 				}
 			}
@@ -156,7 +231,8 @@ func GenerateGoMethodQueriesForPackage(s *schema.Schema, genConfig *config.Gener
 }
 
 // GenerateGoMethodMutationsForPackage uses the provided configuration to generate the GoMethod structs that contain the information about performing GraphQL mutations.
-func GenerateGoMethodMutationsForPackage(s *schema.Schema, genConfig *config.GeneratorConfig, pkgConfig *config.PackageConfig) (*[]GoMethod, error) {
+// When a FragmentSet is received, nested selections are added to it and spread into the query strings.
+func GenerateGoMethodMutationsForPackage(s *schema.Schema, genConfig *config.GeneratorConfig, pkgConfig *config.PackageConfig, fragments *schema.FragmentSet) (*[]GoMethod, error) {
 	var methods []GoMethod
 
 	if len(pkgConfig.Mutations) == 0 {
@@ -168,7 +244,18 @@ func GenerateGoMethodMutationsForPackage(s *schema.Schema, genConfig *config.Gen
 
 		for _, field := range fields {
 			method := goMethodForField(field, pkgConfig, nil)
-			method.QueryString = s.GetQueryStringForMutation(&field, pkgMutation)
+			if fragments != nil {
+				var methodFragments []*schema.Fragment
+				method.QueryString, methodFragments = s.GetQueryStringForMutationWithFragments(&field, pkgMutation, fragments)
+				method.Fragments = goFragmentNames(methodFragments)
+			} else {
+				method.QueryString = s.GetQueryStringForMutation(&field, pkgMutation)
+			}
+
+			method.SelectionType = selectionTypeForField(s, pkgConfig, field)
+			if method.SelectionType != "" {
+				method.SelectionQueryString = s.GetQueryStringForMutationWithSelection(&field, pkgMutation, SelectionQueryPlaceholder)
+			}
 
 			methods = append(methods, method)
 		}
@@ -200,11 +287,23 @@ func GenerateGoTypesForPackage(s *schema.Schema, genConfig *config.GeneratorConf
 		configNames[strings.ToLower(p.Name)] = p
 	}
 
+	// The kinds of the types created in this package, used to determine which
+	// enums and input objects can be validated.
+	generatedKinds := make(map[string]schema.Kind, len(*expandedTypes))
+	for _, t := range *expandedTypes {
+		if p, ok := configNames[strings.ToLower(t.GetName())]; ok && p.SkipTypeCreate {
+			continue
+		}
+
+		generatedKinds[t.Name] = t.Kind
+	}
+
 	for _, t := range *expandedTypes {
 		var interfaceMethods []string
 		var generateGetters bool
 		// Default scalars to string
 		createAs := "string"
+		var hasCreateAs bool
 
 		if p, ok := configNames[strings.ToLower(t.GetName())]; ok {
 			log.WithFields(log.Fields{
@@ -227,6 +326,7 @@ func GenerateGoTypesForPackage(s *schema.Schema, genConfig *config.GeneratorConf
 
 			if p.CreateAs != "" {
 				createAs = p.CreateAs
+				hasCreateAs = true
 			}
 
 			if len(p.InterfaceMethods) > 0 {
@@ -240,6 +340,8 @@ func GenerateGoTypesForPackage(s *schema.Schema, genConfig *config.GeneratorConf
 				Name:            t.GetName(),
 				Description:     t.GetDescription(),
 				GenerateGetters: generateGetters,
+				IsInputObject:   t.Kind == schema.KindInputObject,
+				Validate:        t.Kind == schema.KindInputObject && pkgConfig.ValidateInputs,
 			}
 
 			var fields []schema.Field
@@ -265,7 +367,26 @@ func GenerateGoTypesForPackage(s *schema.Schema, genConfig *config.GeneratorConf
 					xxx.SpecialUnmarshal = true
 				}
 
-				xxx.Fields = append(xxx.Fields, getStructField(f, pkgConfig, t))
+				field := getStructField(f, pkgConfig, t)
+
+				if t.Kind == schema.KindInputObject && pkgConfig.ValidateInputs {
+					field.Validation = getFieldValidation(f, field, generatedKinds)
+				}
+
+				xxx.Fields = append(xxx.Fields, field)
+
+				if t.Kind == schema.KindInputObject && pkgConfig.ExplicitNulls && f.Type.Kind != schema.KindNonNull {
+					xxx.NullableFields = append(xxx.NullableFields, f.Name)
+				}
+			}
+
+			for _, f := range xxx.Fields {
+				if f.Name == "NullFields" && len(xxx.NullableFields) > 0 {
+					log.WithFields(log.Fields{
+						"name": t.Name,
+					}).Warn("skipping explicit nulls for type with a NullFields field")
+					xxx.NullableFields = nil
+				}
 			}
 
 			if len(fieldErrs) > 0 {
@@ -317,17 +438,21 @@ func GenerateGoTypesForPackage(s *schema.Schema, genConfig *config.GeneratorConf
 				return xxx.Fields[i].Name < xxx.Fields[j].Name
 			})
 
+			sort.Strings(xxx.NullableFields)
+
 			structsForGen = append(structsForGen, xxx)
 		case schema.KindENUM:
 			xxx := GoEnum{
 				Name:        t.GetName(),
 				Description: t.GetDescription(),
+				Strict:      pkgConfig.StrictEnums,
 			}
 
 			for _, v := range t.EnumValues {
 				value := GoEnumValue{
 					Name:        v.GetName(),
 					Description: v.GetDescription(),
+					Deprecated:  v.GetDeprecationReason(),
 				}
 
 				xxx.Values = append(xxx.Values, value)
@@ -346,6 +471,14 @@ func GenerateGoTypesForPackage(s *schema.Schema, genConfig *config.GeneratorConf
 					Type:        createAs,
 				}
 
+				// A create_as in the type config takes precedence over the scalar mapping.
+				if scalarConfig := pkgConfig.GetScalarConfigByName(t.Name); scalarConfig != nil && !hasCreateAs {
+					xxx.Type = scalarConfig.Type
+					xxx.Marshaler = scalarConfig.Marshaler
+					xxx.Unmarshaler = scalarConfig.Unmarshaler
+					xxx.Alias = xxx.Marshaler == "" && xxx.Unmarshaler == ""
+				}
+
 				scalarsForGen = append(scalarsForGen, xxx)
 			}
 		// case schema.KindInterface:
@@ -384,6 +517,104 @@ func GenerateGoTypesForPackage(s *schema.Schema, genConfig *config.GeneratorConf
 	return &structsForGen, &enumsForGen, &scalarsForGen, &interfacesForGen, nil
 }
 
+// GenerateGoImportsForPackage returns the configured imports for the package,
+// along with the imports required by the scalar mappings.  Any imports that
+// end up unused are removed when the generated code is formatted.
+func GenerateGoImportsForPackage(pkgConfig *config.PackageConfig) []string {
+	imports := make([]string, 0, len(pkgConfig.Imports))
+	imports = append(imports, pkgConfig.Imports...)
+
+	for _, scalarConfig := range pkgConfig.Scalars {
+		for _, i := range scalarConfig.Imports {
+			if !util.StringInStrings(i, imports) {
+				imports = append(imports, i)
+			}
+		}
+	}
+
+	return imports
+}
+
+// GenerateGoFragmentsForPackage returns the fragments collected while
+// generating the methods for a package.
+func GenerateGoFragmentsForPackage(fragments *schema.FragmentSet) *[]GoFragment {
+	var fragmentsForGen []GoFragment
+
+	if fragments == nil {
+		return &fragmentsForGen
+	}
+
+	for _, f := range fragments.Fragments() {
+		fragmentsForGen = append(fragmentsForGen, GoFragment{
+			Name:        goFragmentName(f.Name),
+			GraphQLName: f.Name,
+			Definition:  f.Definition(),
+		})
+	}
+
+	return &fragmentsForGen
+}
+
+// ValidateGoMethods ensures that the operations sent by each of the received
+// methods are valid GraphQL for the schema the Validator was created from.
+// The fragments are used to resolve the fragment definitions sent along with
+// each method's QueryString.
+func ValidateGoMethods(v *schema.Validator, methods []GoMethod, fragments []GoFragment) error {
+	definitions := make(map[string]string, len(fragments))
+	for _, f := range fragments {
+		definitions[f.Name] = f.Definition
+	}
+
+	var errs schema.ValidationErrors
+
+	validate := func(name string, operation string) {
+		err := v.ValidateOperation(name, operation)
+		if err == nil {
+			return
+		}
+
+		if validationErrs, ok := err.(schema.ValidationErrors); ok {
+			errs = append(errs, validationErrs...)
+			return
+		}
+
+		errs = append(errs, &schema.ValidationError{Operation: name, Message: err.Error()})
+	}
+
+	for _, m := range methods {
+		operation := m.QueryString
+		for _, f := range m.Fragments {
+			operation += "\n" + definitions[f]
+		}
+
+		validate(m.Name, operation)
+
+		// Validate the runtime selection with the smallest possible selection set.
+		if m.SelectionQueryString != "" {
+			validate(m.Name+"WithSelection", fmt.Sprintf(m.SelectionQueryString, "__typename"))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func goFragmentName(name string) string {
+	return (&schema.Type{Name: name}).GetName() + "Fragment"
+}
+
+func goFragmentNames(fragments []*schema.Fragment) []string {
+	names := make([]string, 0, len(fragments))
+	for _, f := range fragments {
+		names = append(names, goFragmentName(f.Name))
+	}
+
+	return names
+}
+
 func getStructField(f schema.Field, pkgConfig *config.PackageConfig, parentType *schema.Type) GoStructField {
 	var typeName string
 	var typeNamePrefix string
@@ -426,6 +657,58 @@ func getStructField(f schema.Field, pkgConfig *config.PackageConfig, parentType
 	}
 }
 
+// getFieldValidation returns the checks for a field of an input object, based
+// on the nullability and kind of the field in the schema.  Enums and input
+// objects are only checked when they are created in the same package, and have
+// not been overridden.
+func getFieldValidation(f schema.Field, field GoStructField, generatedKinds map[string]schema.Kind) *GoFieldValidation {
+	var validation GoFieldValidation
+
+	schemaTypeName, _, _ := f.Type.GetType()
+	overridden := field.TypeName != schemaTypeName
+
+	var kind schema.Kind
+	if !overridden {
+		kind = generatedKinds[f.Type.GetTypeName()]
+	}
+
+	// Only the outer type is considered, a list of non-null values may itself be
+	// null.
+	if f.Type.Kind == schema.KindNonNull {
+		switch {
+		case field.IsList:
+			validation.Zero = "nil"
+		case field.TypeName == "string", kind == schema.KindENUM:
+			validation.Zero = `""`
+		}
+	}
+
+	validation.Enum = kind == schema.KindENUM
+	validation.Nested = kind == schema.KindInputObject
+
+	if validation == (GoFieldValidation{}) {
+		return nil
+	}
+
+	return &validation
+}
+
+// hasInputValidation determines if the input object received by a method has
+// a generated Validate method.
+func hasInputValidation(f schema.Field, pkgConfig *config.PackageConfig, typeName string) bool {
+	if !pkgConfig.ValidateInputs || !f.Type.IsInputObject() {
+		return false
+	}
+
+	if schemaTypeName, _, _ := f.Type.GetType(); typeName != schemaTypeName {
+		return false
+	}
+
+	typeConfig := pkgConfig.GetTypeConfigByName(f.Type.GetTypeName())
+
+	return typeConfig == nil || !typeConfig.SkipTypeCreate
+}
+
 // constrainedResponseStructs is used to create response objects that contain
 // fields that already exist in the expandedTypes.  This avoids creating full
 // structs, and limits response objects to those types that are already
@@ -601,8 +884,10 @@ func goMethodForField(field schema.Field, pkgConfig *config.PackageConfig, input
 		}
 
 		inputType := GoMethodInputType{
-			Name: methodArg.GetName(),
-			Type: fmt.Sprintf("%s%s", methodArgPrefix, typeName),
+			Name:     methodArg.GetName(),
+			Type:     fmt.Sprintf("%s%s", methodArgPrefix, typeName),
+			Validate: hasInputValidation(methodArg, pkgConfig, typeName),
+			IsList:   methodArg.Type.IsList(),
 		}
 
 		method.Signature.Input = append(method.Signature.Input, inputType)
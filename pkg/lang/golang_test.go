@@ -0,0 +1,272 @@
+//go:build unit
+// +build unit
+
+package lang
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/tutone/internal/config"
+	"github.com/newrelic/tutone/internal/schema"
+)
+
+func testScalarTypes() []*schema.Type {
+	return []*schema.Type{
+		{
+			Name: "User",
+			Kind: schema.KindObject,
+			Fields: []schema.Field{
+				{Name: "id", Type: schema.TypeRef{Kind: schema.KindNonNull, OfType: &schema.TypeRef{Name: "ID", Kind: schema.KindScalar}}},
+				{Name: "createdAt", Type: schema.TypeRef{Name: "EpochMilliseconds", Kind: schema.KindScalar}},
+				{Name: "birthday", Type: schema.TypeRef{Name: "NaiveDate", Kind: schema.KindScalar}},
+				{Name: "guid", Type: schema.TypeRef{Name: "EntityGuid", Kind: schema.KindScalar}},
+			},
+		},
+		{Name: "EpochMilliseconds", Kind: schema.KindScalar},
+		{Name: "NaiveDate", Kind: schema.KindScalar},
+		{Name: "EntityGuid", Kind: schema.KindScalar},
+	}
+}
+
+func TestGenerateGoTypesForPackage_Scalars(t *testing.T) {
+	t.Parallel()
+
+	types := testScalarTypes()
+	s := &schema.Schema{Types: types}
+
+	pkgConfig := &config.PackageConfig{
+		Name: "users",
+		Types: []config.TypeConfig{
+			{Name: "EntityGuid", CreateAs: "string"},
+		},
+		Scalars: []config.ScalarConfig{
+			{Name: "ID", Type: "string"},
+			{
+				Name:        "EpochMilliseconds",
+				Type:        "time.Time",
+				Imports:     []string{"time", "example.com/nrtime"},
+				Marshaler:   "nrtime.MarshalEpochMilliseconds",
+				Unmarshaler: "nrtime.UnmarshalEpochMilliseconds",
+			},
+			{Name: "NaiveDate", Type: "civil.Date", Imports: []string{"cloud.google.com/go/civil"}},
+			// The create_as in the type config takes precedence
+			{Name: "EntityGuid", Type: "int"},
+		},
+	}
+
+	structs, _, scalars, _, err := GenerateGoTypesForPackage(s, &config.GeneratorConfig{}, pkgConfig, &types)
+	require.NoError(t, err)
+
+	expectedScalars := []GoScalar{
+		{
+			Name: "EntityGUID",
+			Type: "string",
+		},
+		{
+			Name:        "EpochMilliseconds",
+			Type:        "time.Time",
+			Marshaler:   "nrtime.MarshalEpochMilliseconds",
+			Unmarshaler: "nrtime.UnmarshalEpochMilliseconds",
+		},
+		{
+			Name:  "NaiveDate",
+			Type:  "civil.Date",
+			Alias: true,
+		},
+	}
+	assert.Equal(t, expectedScalars, *scalars)
+
+	require.Len(t, *structs, 1)
+
+	fieldTypes := map[string]string{}
+	for _, f := range (*structs)[0].Fields {
+		fieldTypes[f.Name] = f.Type
+	}
+
+	expectedFieldTypes := map[string]string{
+		"ID":        "string",
+		"CreatedAt": "EpochMilliseconds",
+		"Birthday":  "NaiveDate",
+		"GUID":      "EntityGUID",
+	}
+	assert.Equal(t, expectedFieldTypes, fieldTypes)
+
+	imports := GenerateGoImportsForPackage(&config.PackageConfig{
+		Imports: []string{"time"},
+		Scalars: pkgConfig.Scalars,
+	})
+	assert.Equal(t, []string{"time", "example.com/nrtime", "cloud.google.com/go/civil"}, imports)
+}
+
+func TestGenerateGoTypesForPackage_Enums(t *testing.T) {
+	t.Parallel()
+
+	types := []*schema.Type{
+		{
+			Name:        "KeyType",
+			Kind:        schema.KindENUM,
+			Description: "The type of key",
+			EnumValues: []schema.EnumValue{
+				{Name: "INGEST", Description: "An ingest key"},
+				{Name: "LICENSE", IsDeprecated: true, DeprecationReason: "Use `INGEST`\ninstead"},
+				{Name: "USER", IsDeprecated: true},
+			},
+		},
+	}
+	s := &schema.Schema{Types: types}
+
+	pkgConfig := &config.PackageConfig{
+		Name:        "keys",
+		StrictEnums: true,
+	}
+
+	_, enums, _, _, err := GenerateGoTypesForPackage(s, &config.GeneratorConfig{}, pkgConfig, &types)
+	require.NoError(t, err)
+
+	expected := []GoEnum{
+		{
+			Name:        "KeyType",
+			Description: "// KeyType - The type of key",
+			Strict:      true,
+			Values: []GoEnumValue{
+				{Name: "INGEST", Description: "// An ingest key"},
+				{Name: "LICENSE", Deprecated: "Use `INGEST` instead"},
+				{Name: "USER", Deprecated: "No longer supported"},
+			},
+		},
+	}
+	assert.Equal(t, expected, *enums)
+}
+
+func TestGenerateGoTypesForPackage_ValidateInputs(t *testing.T) {
+	t.Parallel()
+
+	nonNull := func(r schema.TypeRef) schema.TypeRef {
+		return schema.TypeRef{Kind: schema.KindNonNull, OfType: &r}
+	}
+
+	types := []*schema.Type{
+		{
+			Name: "TagInput",
+			Kind: schema.KindInputObject,
+			InputFields: []schema.Field{
+				{Name: "key", Type: nonNull(schema.TypeRef{Name: "String", Kind: schema.KindScalar})},
+				{Name: "count", Type: nonNull(schema.TypeRef{Name: "Int", Kind: schema.KindScalar})},
+				{Name: "kind", Type: schema.TypeRef{Name: "TagKind", Kind: schema.KindENUM}},
+				{Name: "values", Type: nonNull(schema.TypeRef{Kind: schema.KindList, OfType: &schema.TypeRef{Name: "TagValueInput", Kind: schema.KindInputObject}})},
+				{Name: "owner", Type: nonNull(schema.TypeRef{Name: "Owner", Kind: schema.KindInputObject})},
+				{Name: "description", Type: schema.TypeRef{Name: "String", Kind: schema.KindScalar}},
+				{Name: "meta", Type: schema.TypeRef{Name: "TagMetaInput", Kind: schema.KindInputObject}},
+			},
+		},
+		{
+			Name: "TagMetaInput",
+			Kind: schema.KindInputObject,
+			InputFields: []schema.Field{
+				{Name: "note", Type: schema.TypeRef{Name: "String", Kind: schema.KindScalar}},
+			},
+		},
+		{
+			Name: "TagValueInput",
+			Kind: schema.KindInputObject,
+			InputFields: []schema.Field{
+				{Name: "value", Type: schema.TypeRef{Name: "String", Kind: schema.KindScalar}},
+			},
+		},
+		{
+			Name: "TagKind",
+			Kind: schema.KindENUM,
+			EnumValues: []schema.EnumValue{
+				{Name: "USER"},
+			},
+		},
+		{Name: "Owner", Kind: schema.KindInputObject},
+	}
+	s := &schema.Schema{Types: types}
+
+	pkgConfig := &config.PackageConfig{
+		Name:           "tags",
+		ValidateInputs: true,
+		Types: []config.TypeConfig{
+			// Types created elsewhere can't be assumed to have a Validate method
+			{Name: "Owner", SkipTypeCreate: true},
+		},
+	}
+
+	structs, _, _, _, err := GenerateGoTypesForPackage(s, &config.GeneratorConfig{}, pkgConfig, &types)
+	require.NoError(t, err)
+	require.Len(t, *structs, 3)
+
+	tagInput := (*structs)[0]
+	assert.Equal(t, "TagInput", tagInput.Name)
+	assert.True(t, tagInput.Validate)
+
+	validations := map[string]*GoFieldValidation{}
+	for _, f := range tagInput.Fields {
+		validations[f.Name] = f.Validation
+	}
+
+	expected := map[string]*GoFieldValidation{
+		"Count":       nil,
+		"Description": nil,
+		"Key":         {Zero: `""`},
+		"Kind":        {Enum: true},
+		"Meta":        {Nested: true},
+		"Owner":       nil,
+		"Values":      {Zero: "nil", Nested: true},
+	}
+	assert.Equal(t, expected, validations)
+
+	// The nested input objects have a Validate method to call, even with
+	// nothing to validate.
+	for _, nested := range (*structs)[1:] {
+		assert.True(t, nested.Validate, nested.Name)
+
+		for _, f := range nested.Fields {
+			assert.Nil(t, f.Validation, f.Name)
+		}
+	}
+}
+
+func TestGenerateGoTypesForPackage_ExplicitNulls(t *testing.T) {
+	t.Parallel()
+
+	types := []*schema.Type{
+		{
+			Name: "UserInput",
+			Kind: schema.KindInputObject,
+			InputFields: []schema.Field{
+				{Name: "name", Type: schema.TypeRef{Kind: schema.KindNonNull, OfType: &schema.TypeRef{Name: "String", Kind: schema.KindScalar}}},
+				{Name: "nickname", Type: schema.TypeRef{Name: "String", Kind: schema.KindScalar}},
+				{Name: "email", Type: schema.TypeRef{Name: "String", Kind: schema.KindScalar}},
+			},
+		},
+		{
+			Name: "User",
+			Kind: schema.KindObject,
+			Fields: []schema.Field{
+				{Name: "nickname", Type: schema.TypeRef{Name: "String", Kind: schema.KindScalar}},
+			},
+		},
+	}
+	s := &schema.Schema{Types: types}
+
+	pkgConfig := &config.PackageConfig{
+		Name:          "users",
+		ExplicitNulls: true,
+	}
+
+	structs, _, _, _, err := GenerateGoTypesForPackage(s, &config.GeneratorConfig{}, pkgConfig, &types)
+	require.NoError(t, err)
+	require.Len(t, *structs, 2)
+
+	// Output objects can't be sent
+	assert.Equal(t, "User", (*structs)[0].Name)
+	assert.Empty(t, (*structs)[0].NullableFields)
+
+	assert.Equal(t, "UserInput", (*structs)[1].Name)
+	assert.Equal(t, []string{"email", "nickname"}, (*structs)[1].NullableFields)
+}
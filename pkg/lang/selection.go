@@ -0,0 +1,195 @@
+package lang
+
+import (
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/newrelic/tutone/internal/config"
+	"github.com/newrelic/tutone/internal/schema"
+	"github.com/newrelic/tutone/internal/util"
+)
+
+// SelectionQueryPlaceholder is the format verb substituted into a
+// GoMethod.SelectionQueryString, where the rendered selection set is placed at
+// runtime.
+const SelectionQueryPlaceholder = "%s"
+
+// GoSelection is a typed builder for the selection set of a single GraphQL
+// object or interface type.
+type GoSelection struct {
+	// Name is the Go type name of the builder, i.e. EntitySelection
+	Name string
+	// Constructor is the name of the function that returns a new builder, i.e. EntityFields
+	Constructor   string
+	GraphQLName   string
+	IsInterface   bool
+	Fields        []GoSelectionField
+	PossibleTypes []GoSelectionPossibleType
+}
+
+type GoSelectionField struct {
+	// Name is the Go method name used to select the field.
+	Name string
+	// FieldName is the name of the field in GraphQL.
+	FieldName   string
+	Description string
+	// Selection is the name of the builder for the nested type, empty for leaf fields.
+	Selection string
+}
+
+type GoSelectionPossibleType struct {
+	GoName      string
+	GraphQLName string
+	Selection   string
+}
+
+// GenerateGoSelectionsForPackage returns the selection builders for the
+// object and interface types that are expanded for the package, when
+// selection builders are enabled in the package configuration.
+func GenerateGoSelectionsForPackage(s *schema.Schema, pkgConfig *config.PackageConfig, expandedTypes *[]*schema.Type) *[]GoSelection {
+	var selections []GoSelection
+
+	if !pkgConfig.SelectionBuilders || expandedTypes == nil {
+		return &selections
+	}
+
+	for _, t := range *expandedTypes {
+		if !hasSelection(s, pkgConfig, t.Name) {
+			continue
+		}
+
+		sel := GoSelection{
+			Name:        selectionName(t.Name),
+			Constructor: selectionConstructorName(s, t.Name),
+			GraphQLName: t.Name,
+			IsInterface: t.Kind == schema.KindInterface,
+		}
+
+		c := getTypeConfig(t.Name, pkgConfig.Types)
+
+		for _, f := range t.Fields {
+			// Fields with required arguments can't be selected without the
+			// arguments, which the builder has no way to accept.
+			if f.HasRequiredArg() {
+				continue
+			}
+
+			if c != nil && util.StringInStrings(f.GetName(), c.SkipFields) {
+				continue
+			}
+
+			// Avoid a conflict with the method used to render the selection.
+			if f.GetName() == "String" {
+				log.WithFields(log.Fields{
+					"name": f.Name,
+					"type": t.Name,
+				}).Debug("skipping selection field")
+				continue
+			}
+
+			field := GoSelectionField{
+				Name:        f.GetName(),
+				FieldName:   f.Name,
+				Description: f.GetDescription(),
+			}
+
+			if f.Type.IsInterface() || isObjectTypeRef(f.Type) {
+				if !hasSelection(s, pkgConfig, f.Type.GetTypeName()) {
+					continue
+				}
+
+				field.Selection = selectionName(f.Type.GetTypeName())
+			}
+
+			sel.Fields = append(sel.Fields, field)
+		}
+
+		for _, p := range t.PossibleTypes {
+			if !hasSelection(s, pkgConfig, p.Name) {
+				continue
+			}
+
+			sel.PossibleTypes = append(sel.PossibleTypes, GoSelectionPossibleType{
+				GoName:      p.GetName(),
+				GraphQLName: p.Name,
+				Selection:   selectionName(p.Name),
+			})
+		}
+
+		sort.SliceStable(sel.Fields, func(i, j int) bool {
+			return sel.Fields[i].Name < sel.Fields[j].Name
+		})
+
+		selections = append(selections, sel)
+	}
+
+	sort.SliceStable(selections, func(i, j int) bool {
+		return selections[i].Name < selections[j].Name
+	})
+
+	return &selections
+}
+
+// selectionTypeForField returns the name of the selection builder that can be
+// used to select the fields returned by the received field, or an empty string
+// when no builder is generated for the type.
+func selectionTypeForField(s *schema.Schema, pkgConfig *config.PackageConfig, field schema.Field) string {
+	if !pkgConfig.SelectionBuilders {
+		return ""
+	}
+
+	typeName := field.Type.GetTypeName()
+	if !hasSelection(s, pkgConfig, typeName) {
+		return ""
+	}
+
+	return selectionName(typeName)
+}
+
+// hasSelection determines if a selection builder is generated for the named
+// type.  Only object and interface types that are created in the package
+// receive a builder.
+func hasSelection(s *schema.Schema, pkgConfig *config.PackageConfig, typeName string) bool {
+	t, err := s.LookupTypeByName(typeName)
+	if err != nil {
+		return false
+	}
+
+	if t.Kind != schema.KindObject && t.Kind != schema.KindInterface {
+		return false
+	}
+
+	c := getTypeConfig(typeName, pkgConfig.Types)
+	if c != nil && (c.SkipTypeCreate || c.FieldTypeOverride != "") {
+		return false
+	}
+
+	return true
+}
+
+func isObjectTypeRef(r schema.TypeRef) bool {
+	kinds := r.GetKinds()
+
+	return len(kinds) > 0 && kinds[len(kinds)-1] == schema.KindObject
+}
+
+func selectionName(typeName string) string {
+	return (&schema.Type{Name: typeName}).GetName() + "Selection"
+}
+
+// selectionConstructorName returns the name of the function used to start a
+// new selection.  The preferred <Type>Fields name is already taken by some
+// schema types, in which case we fall back to New<Type>Selection.
+func selectionConstructorName(s *schema.Schema, typeName string) string {
+	goName := (&schema.Type{Name: typeName}).GetName()
+	name := goName + "Fields"
+
+	for _, t := range s.Types {
+		if t.GetName() == name {
+			return "New" + goName + "Selection"
+		}
+	}
+
+	return name
+}
@@ -0,0 +1,131 @@
+//go:build unit
+// +build unit
+
+package lang
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/tutone/internal/config"
+	"github.com/newrelic/tutone/internal/schema"
+)
+
+func testSelectionSchema() *schema.Schema {
+	tagType := &schema.Type{
+		Name: "Tag",
+		Kind: schema.KindObject,
+		Fields: []schema.Field{
+			{Name: "key", Type: schema.TypeRef{Name: "String", Kind: schema.KindScalar}},
+			{Name: "string", Type: schema.TypeRef{Name: "String", Kind: schema.KindScalar}},
+		},
+	}
+
+	entityType := &schema.Type{
+		Name: "Entity",
+		Kind: schema.KindInterface,
+		Fields: []schema.Field{
+			{Name: "name", Type: schema.TypeRef{Name: "String", Kind: schema.KindScalar}},
+			{Name: "tags", Type: schema.TypeRef{Kind: schema.KindList, OfType: &schema.TypeRef{Name: "Tag", Kind: schema.KindObject}}},
+			{
+				Name: "relatedTo",
+				Type: schema.TypeRef{Name: "Entity", Kind: schema.KindInterface},
+				Args: []schema.Field{
+					{Name: "guid", Type: schema.TypeRef{Kind: schema.KindNonNull, OfType: &schema.TypeRef{Name: "String", Kind: schema.KindScalar}}},
+				},
+			},
+		},
+		PossibleTypes: []schema.TypeRef{
+			{Name: "ApmEntity", Kind: schema.KindObject},
+		},
+	}
+
+	apmType := &schema.Type{
+		Name: "ApmEntity",
+		Kind: schema.KindObject,
+		Fields: []schema.Field{
+			{Name: "language", Type: schema.TypeRef{Name: "String", Kind: schema.KindScalar}},
+		},
+	}
+
+	// A type whose name conflicts with the default selection constructor name.
+	tagFieldsType := &schema.Type{
+		Name: "TagFields",
+		Kind: schema.KindInputObject,
+	}
+
+	return &schema.Schema{
+		Types: []*schema.Type{tagType, entityType, apmType, tagFieldsType},
+	}
+}
+
+func TestGenerateGoSelectionsForPackage(t *testing.T) {
+	t.Parallel()
+
+	s := testSelectionSchema()
+	expandedTypes := []*schema.Type{s.Types[0], s.Types[1], s.Types[2]}
+
+	disabled := GenerateGoSelectionsForPackage(s, &config.PackageConfig{}, &expandedTypes)
+	require.NotNil(t, disabled)
+	assert.Empty(t, *disabled)
+
+	selections := GenerateGoSelectionsForPackage(s, &config.PackageConfig{SelectionBuilders: true}, &expandedTypes)
+	require.NotNil(t, selections)
+
+	expected := []GoSelection{
+		{
+			Name:        "ApmEntitySelection",
+			Constructor: "ApmEntityFields",
+			GraphQLName: "ApmEntity",
+			Fields: []GoSelectionField{
+				{Name: "Language", FieldName: "language"},
+			},
+		},
+		{
+			Name:        "EntitySelection",
+			Constructor: "EntityFields",
+			GraphQLName: "Entity",
+			IsInterface: true,
+			Fields: []GoSelectionField{
+				{Name: "Name", FieldName: "name"},
+				{Name: "Tags", FieldName: "tags", Selection: "TagSelection"},
+			},
+			PossibleTypes: []GoSelectionPossibleType{
+				{GoName: "ApmEntity", GraphQLName: "ApmEntity", Selection: "ApmEntitySelection"},
+			},
+		},
+		{
+			Name:        "TagSelection",
+			Constructor: "NewTagSelection",
+			GraphQLName: "Tag",
+			Fields: []GoSelectionField{
+				{Name: "Key", FieldName: "key"},
+			},
+		},
+	}
+
+	assert.Equal(t, expected, *selections)
+}
+
+func TestSelectionTypeForField(t *testing.T) {
+	t.Parallel()
+
+	s := testSelectionSchema()
+	field := schema.Field{Name: "entity", Type: schema.TypeRef{Name: "Entity", Kind: schema.KindInterface}}
+	scalarField := schema.Field{Name: "name", Type: schema.TypeRef{Name: "String", Kind: schema.KindScalar}}
+
+	pkgConfig := &config.PackageConfig{SelectionBuilders: true}
+	assert.Equal(t, "EntitySelection", selectionTypeForField(s, pkgConfig, field))
+	assert.Equal(t, "", selectionTypeForField(s, pkgConfig, scalarField))
+	assert.Equal(t, "", selectionTypeForField(s, &config.PackageConfig{}, field))
+
+	skipped := &config.PackageConfig{
+		SelectionBuilders: true,
+		Types: []config.TypeConfig{
+			{Name: "Entity", SkipTypeCreate: true},
+		},
+	}
+	assert.Equal(t, "", selectionTypeForField(s, skipped, field))
+}
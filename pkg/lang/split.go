@@ -0,0 +1,191 @@
+package lang
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/huandu/xstrings"
+)
+
+// The strategies used to split the code generated for a package into several
+// files.
+const (
+	// SplitByKind creates a file for each kind of generated code, i.e. enums,
+	// inputs and mutations.
+	SplitByKind = "kind"
+	// SplitByPrefix creates a file for each of the prefixes of the type and
+	// method names, i.e. alerts and cloud.
+	SplitByPrefix = "prefix"
+	// SplitByOperation creates a file for each query and mutation, leaving the
+	// remainder of the code in a single file.
+	SplitByOperation = "operation"
+)
+
+// GoFile is a part of the code generated for a package, which is rendered to
+// its own file.
+type GoFile struct {
+	// Suffix is added to the configured file name, empty for the file itself.
+	Suffix    string
+	Generator GolangGenerator
+}
+
+// FileName returns the name of the file, given the configured file name.
+func (f GoFile) FileName(fileName string) string {
+	if f.Suffix == "" {
+		return fileName
+	}
+
+	ext := ""
+	if i := strings.LastIndex(fileName, "."); i > 0 {
+		ext = fileName[i:]
+	}
+
+	return fmt.Sprintf("%s_%s%s", strings.TrimSuffix(fileName, ext), f.Suffix, ext)
+}
+
+// SplitGolangGenerator divides the contents of the generator into files
+// according to the received strategy.  When no strategy is received, a single
+// file contains all of the code.  The files are sorted by suffix, and none are
+// returned without contents.
+func SplitGolangGenerator(g GolangGenerator, strategy string) ([]GoFile, error) {
+	files := map[string]*GolangGenerator{}
+
+	file := func(suffix string) *GolangGenerator {
+		if f, ok := files[suffix]; ok {
+			return f
+		}
+
+		f := &GolangGenerator{
+			PackageName: g.PackageName,
+			Imports:     g.Imports,
+		}
+		files[suffix] = f
+
+		return f
+	}
+
+	switch strategy {
+	case "":
+		return []GoFile{{Generator: g}}, nil
+	case SplitByKind:
+		for _, x := range g.Types {
+			if x.IsInputObject {
+				file("inputs").Types = append(file("inputs").Types, x)
+			} else {
+				file("types").Types = append(file("types").Types, x)
+			}
+		}
+
+		for _, x := range g.Enums {
+			file("enums").Enums = append(file("enums").Enums, x)
+		}
+
+		for _, x := range g.Scalars {
+			file("scalars").Scalars = append(file("scalars").Scalars, x)
+		}
+
+		for _, x := range g.Interfaces {
+			file("interfaces").Interfaces = append(file("interfaces").Interfaces, x)
+		}
+
+		for _, x := range g.Mutations {
+			file("mutations").Mutations = append(file("mutations").Mutations, x)
+		}
+
+		for _, x := range g.Queries {
+			file("queries").Queries = append(file("queries").Queries, x)
+		}
+	case SplitByPrefix:
+		for _, x := range g.Types {
+			f := file(namePrefix(x.Name))
+			f.Types = append(f.Types, x)
+		}
+
+		for _, x := range g.Enums {
+			f := file(namePrefix(x.Name))
+			f.Enums = append(f.Enums, x)
+		}
+
+		for _, x := range g.Scalars {
+			f := file(namePrefix(x.Name))
+			f.Scalars = append(f.Scalars, x)
+		}
+
+		for _, x := range g.Interfaces {
+			f := file(namePrefix(x.Name))
+			f.Interfaces = append(f.Interfaces, x)
+		}
+
+		for _, x := range g.Mutations {
+			f := file(namePrefix(x.Name))
+			f.Mutations = append(f.Mutations, x)
+		}
+
+		for _, x := range g.Queries {
+			f := file(namePrefix(x.Name))
+			f.Queries = append(f.Queries, x)
+		}
+	case SplitByOperation:
+		main := file("")
+		main.Types = g.Types
+		main.Enums = g.Enums
+		main.Scalars = g.Scalars
+		main.Interfaces = g.Interfaces
+		main.Selections = g.Selections
+		main.Fragments = g.Fragments
+
+		// Named after the generated methods, since a query and a mutation may
+		// share a name.
+		for _, x := range g.Mutations {
+			f := file(xstrings.ToSnakeCase(x.Name))
+			f.Mutations = append(f.Mutations, x)
+		}
+
+		for _, x := range g.Queries {
+			f := file("get_" + xstrings.ToSnakeCase(x.Name))
+			f.Queries = append(f.Queries, x)
+		}
+	default:
+		return nil, fmt.Errorf("unknown split strategy %q, must be one of %s, %s or %s", strategy, SplitByKind, SplitByPrefix, SplitByOperation)
+	}
+
+	// The selections and fragments depend on shared declarations, so are always
+	// kept together.
+	if strategy != SplitByOperation {
+		file("selections").Selections = g.Selections
+		file("fragments").Fragments = g.Fragments
+	}
+
+	var result []GoFile
+	for suffix, f := range files {
+		if f.isEmpty() {
+			continue
+		}
+
+		result = append(result, GoFile{Suffix: suffix, Generator: *f})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Suffix < result[j].Suffix
+	})
+
+	return result, nil
+}
+
+func (g *GolangGenerator) isEmpty() bool {
+	return len(g.Types) == 0 &&
+		len(g.Enums) == 0 &&
+		len(g.Scalars) == 0 &&
+		len(g.Interfaces) == 0 &&
+		len(g.Mutations) == 0 &&
+		len(g.Queries) == 0 &&
+		len(g.Selections) == 0 &&
+		len(g.Fragments) == 0
+}
+
+// namePrefix returns the first word of a type or method name, i.e. alerts for
+// AlertsPolicyInput.
+func namePrefix(name string) string {
+	return strings.SplitN(xstrings.ToSnakeCase(name), "_", 2)[0]
+}
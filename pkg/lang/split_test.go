@@ -0,0 +1,78 @@
+//go:build unit
+// +build unit
+
+package lang
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSplitGenerator() GolangGenerator {
+	return GolangGenerator{
+		PackageName: "alerts",
+		Imports:     []string{"fmt"},
+		Types: []GoStruct{
+			{Name: "AlertsPolicy"},
+			{Name: "AlertsPolicyInput", IsInputObject: true},
+			{Name: "CloudProvider"},
+		},
+		Enums:      []GoEnum{{Name: "AlertsIncidentPreference"}},
+		Mutations:  []GoMethod{{Name: "alertsPolicyCreate"}},
+		Queries:    []GoMethod{{Name: "alertsPolicy"}},
+		Selections: []GoSelection{{Name: "AlertsPolicySelection"}},
+	}
+}
+
+func TestSplitGolangGenerator(t *testing.T) {
+	t.Parallel()
+
+	g := testSplitGenerator()
+
+	suffixes := func(files []GoFile) []string {
+		var result []string
+		for _, f := range files {
+			result = append(result, f.Suffix)
+		}
+		return result
+	}
+
+	files, err := SplitGolangGenerator(g, "")
+	require.NoError(t, err)
+	assert.Equal(t, []GoFile{{Generator: g}}, files)
+
+	files, err = SplitGolangGenerator(g, SplitByKind)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"enums", "inputs", "mutations", "queries", "selections", "types"}, suffixes(files))
+	assert.Equal(t, []GoStruct{{Name: "AlertsPolicyInput", IsInputObject: true}}, files[1].Generator.Types)
+
+	files, err = SplitGolangGenerator(g, SplitByPrefix)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alerts", "cloud", "selections"}, suffixes(files))
+	assert.Len(t, files[0].Generator.Types, 2)
+	assert.Len(t, files[0].Generator.Mutations, 1)
+
+	files, err = SplitGolangGenerator(g, SplitByOperation)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "alerts_policy_create", "get_alerts_policy"}, suffixes(files))
+	assert.Len(t, files[0].Generator.Types, 3)
+	assert.Len(t, files[0].Generator.Selections, 1)
+
+	for _, f := range files {
+		assert.Equal(t, "alerts", f.Generator.PackageName)
+		assert.Equal(t, []string{"fmt"}, f.Generator.Imports)
+	}
+
+	_, err = SplitGolangGenerator(g, "unknown")
+	assert.EqualError(t, err, `unknown split strategy "unknown", must be one of kind, prefix or operation`)
+}
+
+func TestGoFile_FileName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "types.go", GoFile{}.FileName("types.go"))
+	assert.Equal(t, "types_enums.go", GoFile{Suffix: "enums"}.FileName("types.go"))
+	assert.Equal(t, "types_enums", GoFile{Suffix: "enums"}.FileName("types"))
+}
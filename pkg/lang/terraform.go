@@ -0,0 +1,714 @@
+package lang
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/newrelic/tutone/internal/config"
+	"github.com/newrelic/tutone/internal/schema"
+	"github.com/newrelic/tutone/internal/util"
+)
+
+// TerraformGenerator is enough information to generate the Terraform resources
+// of a single package.
+type TerraformGenerator struct {
+	PackageName string
+	Imports     []string
+	Resources   []TerraformResource
+	// Expanders and Flatteners convert between the attributes of the resources
+	// and the client types, shared by all of the resources.
+	Expanders  []TerraformConverter
+	Flatteners []TerraformConverter
+}
+
+// TerraformResource is a resource, with its schema and CRUD functions.
+type TerraformResource struct {
+	// Name is the resource type, i.e. newrelic_alert_policy
+	Name string
+	// FuncName is the name of the function returning the resource, i.e.
+	// resourceNewrelicAlertPolicy
+	FuncName    string
+	Description string
+	Attributes  []TerraformAttribute
+	// Client is the Go expression of the client, given the meta of the provider.
+	Client string
+	Create *TerraformOperation
+	Read   *TerraformOperation
+	Update *TerraformOperation
+	Delete *TerraformOperation
+}
+
+// TerraformAttribute is an attribute of the schema of a resource, or of a
+// nested block.
+type TerraformAttribute struct {
+	Name        string
+	Description string
+	// Type is the schema.ValueType of the attribute, i.e. TypeString
+	Type string
+	// ElemType is the schema.ValueType of the elements of a list of values.
+	ElemType string
+	Required bool
+	ForceNew bool
+	// MaxItems is one for a block that isn't a list.
+	MaxItems int
+	// Attributes are the attributes of a nested block.
+	Attributes []TerraformAttribute
+	// ValidValues are the values of an enum, which the attribute is validated
+	// against.
+	ValidValues []string
+}
+
+// TerraformOperation is the call of a client method by a CRUD function.
+type TerraformOperation struct {
+	// Method is the name of the client method.
+	Method string
+	// Args are the Go expressions of the arguments of the method.
+	Args []string
+	// UsesInput is set when an argument is expanded from the attributes.
+	UsesInput bool
+	// ParseID is set when an argument is the ID parsed as an int.
+	ParseID bool
+	// ReturnSlice is set when the method returns a list, whose first element
+	// is the result.
+	ReturnSlice bool
+	// IDField is the Go field of the result holding the ID, empty when the
+	// result is the ID itself.  Only set for a create.
+	IDField string
+	// Flatten is the name of the function flattening the result into the
+	// attributes.  Only set for a read.
+	Flatten string
+}
+
+// TerraformConverter is a function converting the attributes of a resource, or
+// of a nested block, to a client type, or the client type to the attributes.
+type TerraformConverter struct {
+	Name   string
+	Type   string
+	Fields []TerraformField
+}
+
+// TerraformField is the conversion of a single field of a client type.
+type TerraformField struct {
+	Attribute string
+	GoName    string
+	// Kind is one of "value", "values", "block" or "blocks".
+	Kind string
+	// Type is the Go type of a value, converted from the AttributeType.
+	Type          string
+	AttributeType string
+	// Func is the converter of a block.
+	Func string
+}
+
+// terraformValue is the Terraform and Go types of a scalar or enum.
+type terraformValue struct {
+	schemaType    string
+	attributeType string
+	goType        string
+}
+
+// GenerateTerraformForPackage returns the resources configured for the
+// package.  The attributes of each resource are the fields of its input type,
+// and of the scalar arguments of its create mutation.
+func GenerateTerraformForPackage(s *schema.Schema, pkgConfig *config.PackageConfig) (*TerraformGenerator, error) {
+	g := &TerraformGenerator{
+		PackageName: pkgConfig.Name,
+		Imports:     pkgConfig.Imports,
+	}
+
+	b := &terraformBuilder{
+		schema:     s,
+		pkgConfig:  pkgConfig,
+		generator:  g,
+		expanders:  map[string]bool{},
+		flatteners: map[string]bool{},
+	}
+
+	for _, r := range pkgConfig.Resources {
+		resource, err := b.resource(r)
+		if err != nil {
+			return nil, fmt.Errorf("resource %s: %w", r.Name, err)
+		}
+
+		g.Resources = append(g.Resources, *resource)
+	}
+
+	return g, nil
+}
+
+type terraformBuilder struct {
+	schema        *schema.Schema
+	pkgConfig     *config.PackageConfig
+	generator     *TerraformGenerator
+	clientPackage string
+	// expanders and flatteners are the names of the converters added.
+	expanders  map[string]bool
+	flatteners map[string]bool
+}
+
+func (b *terraformBuilder) resource(r config.Resource) (*TerraformResource, error) {
+	if r.Create == "" || r.Read == "" || r.Delete == "" {
+		return nil, fmt.Errorf("create, read and delete are required")
+	}
+
+	if r.Client == "" && r.ClientPackageName == "" {
+		return nil, fmt.Errorf("client or clientPackageName is required")
+	}
+
+	b.clientPackage = r.ClientPackageName
+
+	inputType, err := b.schema.LookupTypeByName(r.InputType)
+	if err != nil {
+		return nil, err
+	}
+
+	if inputType.Kind != schema.KindInputObject {
+		return nil, fmt.Errorf("%s is not an input object", r.InputType)
+	}
+
+	resource := &TerraformResource{
+		Name:        r.Name,
+		FuncName:    "resource" + terraformGoName(r.Name),
+		Description: strings.TrimSpace(inputType.Description),
+		Attributes:  b.attributes(inputType, []string{inputType.Name}),
+		Client:      r.Client,
+	}
+
+	if resource.Client == "" {
+		resource.Client = "meta.(*ProviderConfig).NewClient." + strings.Title(r.ClientPackageName)
+	}
+
+	// The create comes first, adding its scalar arguments to the attributes.
+	if resource.Create, err = b.mutation(resource, r.Create, true); err != nil {
+		return nil, err
+	}
+
+	if err = b.createResult(resource.Create, r); err != nil {
+		return nil, err
+	}
+
+	if resource.Read, err = b.query(resource, r.Read); err != nil {
+		return nil, err
+	}
+
+	if r.Update != "" {
+		if resource.Update, err = b.mutation(resource, r.Update, false); err != nil {
+			return nil, err
+		}
+	} else {
+		for i := range resource.Attributes {
+			resource.Attributes[i].ForceNew = true
+		}
+	}
+
+	if resource.Delete, err = b.mutation(resource, r.Delete, false); err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// attributes returns the attributes of the fields of the input object, where
+// seen are the input objects containing it, which are not nested again.
+func (b *terraformBuilder) attributes(t *schema.Type, seen []string) []TerraformAttribute {
+	var attributes []TerraformAttribute
+
+	for _, f := range t.InputFields {
+		attribute := TerraformAttribute{
+			Name:        util.ToSnakeCase(f.Name),
+			Description: strings.TrimSpace(f.Description),
+			Required:    f.Type.Kind == schema.KindNonNull,
+		}
+
+		kinds := f.Type.GetKinds()
+
+		switch kinds[len(kinds)-1] {
+		case schema.KindScalar, schema.KindENUM:
+			value, ok := b.value(f)
+			if !ok {
+				log.WithFields(log.Fields{
+					"field": f.Name,
+					"type":  t.Name,
+				}).Warn("skipping attribute, the type of the field is not supported")
+				continue
+			}
+
+			attribute.Type = value.schemaType
+			if f.Type.IsList() {
+				attribute.Type = "TypeList"
+				attribute.ElemType = value.schemaType
+			}
+
+			attribute.ValidValues = b.enumValues(f)
+		case schema.KindInputObject:
+			name := f.Type.GetTypeName()
+			if util.StringInStrings(name, seen) {
+				log.WithFields(log.Fields{
+					"field": f.Name,
+					"type":  t.Name,
+				}).Warn("skipping attribute, the input object is recursive")
+				continue
+			}
+
+			nested, err := b.schema.LookupTypeByName(name)
+			if err != nil {
+				log.Error(err)
+				continue
+			}
+
+			attribute.Type = "TypeList"
+			attribute.Attributes = b.attributes(nested, append(seen, name))
+
+			if !f.Type.IsList() {
+				attribute.MaxItems = 1
+			}
+		default:
+			continue
+		}
+
+		attributes = append(attributes, attribute)
+	}
+
+	return attributes
+}
+
+// value returns the types of a scalar or enum field, which are not supported
+// when the Go type is overridden with a type that can't be converted from an
+// attribute.
+func (b *terraformBuilder) value(f schema.Field) (terraformValue, bool) {
+	goType, err := f.GetTypeNameWithOverride(b.pkgConfig)
+	if err != nil {
+		return terraformValue{}, false
+	}
+
+	if value, ok := terraformValueTypes[goType]; ok {
+		return value, true
+	}
+
+	name := f.Type.GetTypeName()
+
+	t, err := b.schema.LookupTypeByName(name)
+	if err != nil || goType != t.GetName() {
+		return terraformValue{}, false
+	}
+
+	switch t.Kind {
+	case schema.KindENUM:
+		value := terraformValueTypes["string"]
+		value.goType = b.qualify(goType)
+
+		return value, true
+	case schema.KindScalar:
+		if b.pkgConfig.GetScalarConfigByName(name) != nil {
+			return terraformValue{}, false
+		}
+
+		createAs := "string"
+		if typeConfig := b.pkgConfig.GetTypeConfigByName(name); typeConfig != nil && typeConfig.CreateAs != "" {
+			createAs = typeConfig.CreateAs
+		}
+
+		value, ok := terraformValueTypes[createAs]
+		value.goType = b.qualify(goType)
+
+		return value, ok
+	}
+
+	return terraformValue{}, false
+}
+
+// terraformValueTypes are the Terraform types of the Go types.
+var terraformValueTypes = map[string]terraformValue{
+	"string":  {schemaType: "TypeString", attributeType: "string", goType: "string"},
+	"int":     {schemaType: "TypeInt", attributeType: "int", goType: "int"},
+	"float64": {schemaType: "TypeFloat", attributeType: "float64", goType: "float64"},
+	"bool":    {schemaType: "TypeBool", attributeType: "bool", goType: "bool"},
+}
+
+// enumValues returns the values of an enum field, or nil.
+func (b *terraformBuilder) enumValues(f schema.Field) []string {
+	t, err := b.schema.LookupTypeByName(f.Type.GetTypeName())
+	if err != nil || t.Kind != schema.KindENUM {
+		return nil
+	}
+
+	values := make([]string, len(t.EnumValues))
+	for i, v := range t.EnumValues {
+		values[i] = v.Name
+	}
+
+	return values
+}
+
+// qualify prefixes the name of a client type with the client package.
+func (b *terraformBuilder) qualify(goType string) string {
+	if b.clientPackage == "" || strings.Contains(goType, ".") {
+		return goType
+	}
+
+	return b.clientPackage + "." + goType
+}
+
+// mutation returns the call of the configured mutation.
+func (b *terraformBuilder) mutation(resource *TerraformResource, name string, create bool) (*TerraformOperation, error) {
+	if !mutationConfigured(b.pkgConfig, name) {
+		return nil, fmt.Errorf("mutation %s is not configured in the package", name)
+	}
+
+	field, err := b.schema.LookupMutationByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	op := &TerraformOperation{
+		Method:      strings.Title(field.GetName()),
+		ReturnSlice: field.Type.IsList(),
+	}
+
+	for _, arg := range field.Args {
+		expr, err := b.argument(resource, op, arg, util.ToSnakeCase(arg.Name), create)
+		if err != nil {
+			return nil, fmt.Errorf("mutation %s: %w", name, err)
+		}
+
+		op.Args = append(op.Args, expr)
+	}
+
+	return op, nil
+}
+
+func mutationConfigured(pkgConfig *config.PackageConfig, name string) bool {
+	for _, m := range pkgConfig.Mutations {
+		if mutationNameMatches(m.Name, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mutationNameMatches determines if the mutation name matches the pattern of a
+// configured mutation, see schema.LookupMutationsByPattern.
+func mutationNameMatches(pattern string, name string) bool {
+	s := &schema.Schema{MutationType: &schema.Type{Fields: []schema.Field{{Name: name}}}}
+
+	return len(s.LookupMutationsByPattern(pattern)) > 0
+}
+
+// query returns the call of the configured query endpoint, whose result is
+// flattened into the attributes.
+func (b *terraformBuilder) query(resource *TerraformResource, name string) (*TerraformOperation, error) {
+	for _, pkgQuery := range b.pkgConfig.Queries {
+		for _, endpoint := range pkgQuery.Endpoints {
+			if endpoint.Name != name {
+				continue
+			}
+
+			typePath, err := b.schema.LookupQueryTypesByFieldPath(pkgQuery.Path)
+			if err != nil {
+				return nil, err
+			}
+
+			field, err := typePath[len(typePath)-1].GetField(name)
+			if err != nil {
+				return nil, err
+			}
+
+			op := &TerraformOperation{
+				Method:      "Get" + strings.Title(field.GetName()),
+				ReturnSlice: field.Type.IsList(),
+			}
+
+			// The required arguments of the path come first, as in the client,
+			// named after the field of the path, i.e. the id of the account is
+			// account_id.
+			inputFields := b.schema.GetInputFieldsForQueryPath(pkgQuery.Path)
+
+			for _, pathName := range pkgQuery.Path {
+				for _, f := range inputFields[pathName] {
+					if !f.Type.IsNonNull() {
+						continue
+					}
+
+					expr, err := b.argument(resource, op, f, util.ToSnakeCase(pathName+strings.Title(f.Name)), false)
+					if err != nil {
+						return nil, fmt.Errorf("query %s: %w", name, err)
+					}
+
+					op.Args = append(op.Args, expr)
+				}
+			}
+
+			for _, arg := range field.Args {
+				expr, err := b.argument(resource, op, arg, util.ToSnakeCase(arg.Name), false)
+				if err != nil {
+					return nil, fmt.Errorf("query %s: %w", name, err)
+				}
+
+				op.Args = append(op.Args, expr)
+			}
+
+			if field.Type.IsInterface() {
+				return nil, fmt.Errorf("query %s returns an interface, which can't be flattened", name)
+			}
+
+			resultType, err := b.schema.LookupTypeByName(field.Type.GetTypeName())
+			if err != nil {
+				return nil, err
+			}
+
+			op.Flatten = b.flattener(resultType, resource.Attributes)
+
+			return op, nil
+		}
+	}
+
+	return nil, fmt.Errorf("query endpoint %s is not configured in the package", name)
+}
+
+// argument returns the Go expression of an argument of a client method.  Input
+// objects are expanded from the attributes, scalars and enums are the value of
+// the named attribute, which the create adds, and otherwise the ID.
+func (b *terraformBuilder) argument(resource *TerraformResource, op *TerraformOperation, arg schema.Field, name string, create bool) (string, error) {
+	kinds := arg.Type.GetKinds()
+
+	switch kinds[len(kinds)-1] {
+	case schema.KindInputObject:
+		t, err := b.schema.LookupTypeByName(arg.Type.GetTypeName())
+		if err != nil {
+			return "", err
+		}
+
+		op.UsesInput = true
+		expr := b.expander(t, resource.Attributes) + "(in)"
+
+		if arg.Type.IsList() {
+			return fmt.Sprintf("[]%s{%s}", b.qualify(t.GetName()), expr), nil
+		}
+
+		return expr, nil
+	case schema.KindScalar, schema.KindENUM:
+		value, ok := b.value(arg)
+		if !ok || arg.Type.IsList() {
+			return "", fmt.Errorf("the type of argument %s is not supported", arg.Name)
+		}
+
+		if create && findAttribute(resource.Attributes, name) == nil {
+			resource.Attributes = append(resource.Attributes, TerraformAttribute{
+				Name:        name,
+				Description: strings.TrimSpace(arg.Description),
+				Type:        value.schemaType,
+				Required:    arg.Type.Kind == schema.KindNonNull,
+				ForceNew:    true,
+				ValidValues: b.enumValues(arg),
+			})
+		}
+
+		if findAttribute(resource.Attributes, name) != nil {
+			return convert(value, fmt.Sprintf("d.Get(%q).(%s)", name, value.attributeType)), nil
+		}
+
+		switch value.attributeType {
+		case "string":
+			return convert(value, "d.Id()"), nil
+		case "int":
+			op.ParseID = true
+			return convert(value, "id"), nil
+		}
+
+		return "", fmt.Errorf("argument %s of type %s can't be the ID", arg.Name, value.goType)
+	}
+
+	return "", fmt.Errorf("the type of argument %s is not supported", arg.Name)
+}
+
+// convert returns the expression converting the value of an attribute to the
+// Go type.
+func convert(value terraformValue, expr string) string {
+	if value.goType == value.attributeType {
+		return expr
+	}
+
+	return fmt.Sprintf("%s(%s)", value.goType, expr)
+}
+
+// createResult sets the field of the result of the create holding the ID.
+func (b *terraformBuilder) createResult(op *TerraformOperation, r config.Resource) error {
+	field, err := b.schema.LookupMutationByName(r.Create)
+	if err != nil {
+		return err
+	}
+
+	resultType, err := b.schema.LookupTypeByName(field.Type.GetTypeName())
+	if err != nil {
+		return err
+	}
+
+	if resultType.Kind == schema.KindScalar {
+		return nil
+	}
+
+	idField := r.IDField
+	if idField == "" {
+		idField = "id"
+	}
+
+	f, err := resultType.GetField(idField)
+	if err != nil {
+		return fmt.Errorf("no field %s on the result of %s: %w", idField, r.Create, err)
+	}
+
+	op.IDField = f.GetName()
+
+	return nil
+}
+
+// expander returns the name of the function expanding the attributes to the
+// input object, which is added for the first attributes it's expanded from.
+func (b *terraformBuilder) expander(t *schema.Type, attributes []TerraformAttribute) string {
+	name := "expand" + t.GetName()
+	if b.expanders[name] {
+		return name
+	}
+
+	b.expanders[name] = true
+
+	converter := TerraformConverter{
+		Name: name,
+		Type: b.qualify(t.GetName()),
+	}
+
+	for _, f := range t.InputFields {
+		field, ok := b.field(f, attributes, b.expander)
+		if ok {
+			converter.Fields = append(converter.Fields, field)
+		}
+	}
+
+	b.generator.Expanders = append(b.generator.Expanders, converter)
+
+	return name
+}
+
+// flattener returns the name of the function flattening the object to the
+// attributes, which is added for the first attributes it's flattened to.
+func (b *terraformBuilder) flattener(t *schema.Type, attributes []TerraformAttribute) string {
+	name := "flatten" + t.GetName()
+	if b.flatteners[name] {
+		return name
+	}
+
+	b.flatteners[name] = true
+
+	converter := TerraformConverter{
+		Name: name,
+		Type: b.qualify(t.GetName()),
+	}
+
+	for _, f := range t.Fields {
+		if f.HasRequiredArg() || f.Type.IsInterface() {
+			continue
+		}
+
+		field, ok := b.field(f, attributes, b.flattener)
+		if ok {
+			converter.Fields = append(converter.Fields, field)
+		}
+	}
+
+	b.generator.Flatteners = append(b.generator.Flatteners, converter)
+
+	return name
+}
+
+// field returns the conversion of a field to the attribute of the same name,
+// unless there is no such attribute or its type doesn't match.  Blocks are
+// converted by the functions returned by the converter.
+func (b *terraformBuilder) field(f schema.Field, attributes []TerraformAttribute, converter func(*schema.Type, []TerraformAttribute) string) (TerraformField, bool) {
+	attribute := findAttribute(attributes, util.ToSnakeCase(f.Name))
+	if attribute == nil {
+		return TerraformField{}, false
+	}
+
+	field := TerraformField{
+		Attribute: attribute.Name,
+		GoName:    f.GetName(),
+	}
+
+	kinds := f.Type.GetKinds()
+
+	switch kinds[len(kinds)-1] {
+	case schema.KindScalar, schema.KindENUM:
+		value, ok := b.value(f)
+		if !ok || attribute.Attributes != nil {
+			return TerraformField{}, false
+		}
+
+		field.Kind = "value"
+		field.Type = value.goType
+		field.AttributeType = value.attributeType
+
+		if f.Type.IsList() {
+			if attribute.ElemType != value.schemaType {
+				return TerraformField{}, false
+			}
+
+			field.Kind = "values"
+		} else if attribute.Type != value.schemaType {
+			return TerraformField{}, false
+		}
+	case schema.KindInputObject, schema.KindObject:
+		if attribute.Attributes == nil {
+			return TerraformField{}, false
+		}
+
+		t, err := b.schema.LookupTypeByName(f.Type.GetTypeName())
+		if err != nil {
+			return TerraformField{}, false
+		}
+
+		// An overridden type can't be converted to or from the block.
+		if goType, err := f.GetTypeNameWithOverride(b.pkgConfig); err != nil || goType != t.GetName() {
+			return TerraformField{}, false
+		}
+
+		field.Kind = "block"
+		if f.Type.IsList() {
+			// A list can't be set to a single block.
+			if attribute.MaxItems == 1 {
+				return TerraformField{}, false
+			}
+
+			field.Kind = "blocks"
+		}
+
+		field.Func = converter(t, attribute.Attributes)
+	default:
+		return TerraformField{}, false
+	}
+
+	return field, true
+}
+
+func findAttribute(attributes []TerraformAttribute, name string) *TerraformAttribute {
+	for i := range attributes {
+		if attributes[i].Name == name {
+			return &attributes[i]
+		}
+	}
+
+	return nil
+}
+
+// terraformGoName returns the Go name of a resource type, i.e.
+// newrelic_alert_policy is NewrelicAlertPolicy
+func terraformGoName(name string) string {
+	parts := strings.Split(name, "_")
+	for i, p := range parts {
+		parts[i] = strings.Title(p)
+	}
+
+	return strings.Join(parts, "")
+}
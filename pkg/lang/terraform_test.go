@@ -0,0 +1,186 @@
+//go:build unit
+// +build unit
+
+package lang
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/tutone/internal/config"
+	"github.com/newrelic/tutone/internal/schema"
+)
+
+func testTerraformSchema() *schema.Schema {
+	nonNull := func(r schema.TypeRef) schema.TypeRef {
+		return schema.TypeRef{Kind: schema.KindNonNull, OfType: &r}
+	}
+	list := func(r schema.TypeRef) schema.TypeRef {
+		return schema.TypeRef{Kind: schema.KindList, OfType: &r}
+	}
+
+	stringRef := schema.TypeRef{Name: "String", Kind: schema.KindScalar}
+	mutations := []schema.Field{
+		{
+			Name: "tagCreate",
+			Type: schema.TypeRef{Name: "Tag", Kind: schema.KindObject},
+			Args: []schema.Field{
+				{Name: "guid", Type: nonNull(stringRef)},
+				{Name: "tag", Type: nonNull(schema.TypeRef{Name: "TagInput", Kind: schema.KindInputObject})},
+			},
+		},
+		{
+			Name: "tagDelete",
+			Type: schema.TypeRef{Name: "Boolean", Kind: schema.KindScalar},
+			Args: []schema.Field{
+				{Name: "guid", Type: nonNull(stringRef)},
+				{Name: "key", Type: nonNull(stringRef)},
+			},
+		},
+	}
+
+	return &schema.Schema{
+		MutationType: &schema.Type{Name: "RootMutationType", Fields: mutations},
+		Types: []*schema.Type{
+			{Name: "RootQueryType", Kind: schema.KindObject, Fields: []schema.Field{
+				{Name: "actor", Type: schema.TypeRef{Name: "Actor", Kind: schema.KindObject}},
+			}},
+			{Name: "Actor", Kind: schema.KindObject, Fields: []schema.Field{
+				{Name: "tags", Type: list(schema.TypeRef{Name: "Tag", Kind: schema.KindObject}), Args: []schema.Field{
+					{Name: "guid", Type: nonNull(stringRef)},
+				}},
+			}},
+			{Name: "Tag", Kind: schema.KindObject, Fields: []schema.Field{
+				{Name: "key", Type: stringRef},
+				{Name: "values", Type: list(stringRef)},
+				{Name: "kind", Type: schema.TypeRef{Name: "TagKind", Kind: schema.KindENUM}},
+				{Name: "meta", Type: schema.TypeRef{Name: "TagMeta", Kind: schema.KindObject}},
+			}},
+			{Name: "TagMeta", Kind: schema.KindObject, Fields: []schema.Field{
+				{Name: "priority", Type: schema.TypeRef{Name: "Int", Kind: schema.KindScalar}},
+			}},
+			{Name: "TagInput", Kind: schema.KindInputObject, Description: "A tag to create", InputFields: []schema.Field{
+				{Name: "key", Type: nonNull(stringRef), Description: "The key"},
+				{Name: "values", Type: list(nonNull(stringRef))},
+				{Name: "kind", Type: schema.TypeRef{Name: "TagKind", Kind: schema.KindENUM}},
+				{Name: "meta", Type: schema.TypeRef{Name: "TagMetaInput", Kind: schema.KindInputObject}},
+				{Name: "createdAt", Type: schema.TypeRef{Name: "EpochMilliseconds", Kind: schema.KindScalar}},
+			}},
+			{Name: "TagMetaInput", Kind: schema.KindInputObject, InputFields: []schema.Field{
+				{Name: "priority", Type: schema.TypeRef{Name: "Int", Kind: schema.KindScalar}},
+				{Name: "parent", Type: schema.TypeRef{Name: "TagMetaInput", Kind: schema.KindInputObject}},
+			}},
+			{Name: "TagKind", Kind: schema.KindENUM, EnumValues: []schema.EnumValue{{Name: "USER"}, {Name: "SYSTEM"}}},
+			{Name: "EpochMilliseconds", Kind: schema.KindScalar},
+			{Name: "String", Kind: schema.KindScalar},
+			{Name: "Int", Kind: schema.KindScalar},
+			{Name: "Boolean", Kind: schema.KindScalar},
+		},
+	}
+}
+
+func TestGenerateTerraformForPackage(t *testing.T) {
+	t.Parallel()
+
+	pkgConfig := &config.PackageConfig{
+		Name:      "newrelic",
+		Mutations: []config.MutationConfig{{Name: "tag.*"}},
+		Queries:   []config.Query{{Path: []string{"actor"}, Endpoints: []config.EndpointConfig{{Name: "tags"}}}},
+		Types:     []config.TypeConfig{{Name: "EpochMilliseconds", FieldTypeOverride: "*nrtime.EpochMilliseconds"}},
+		Resources: []config.Resource{{
+			Name:              "newrelic_tag",
+			InputType:         "TagInput",
+			ClientPackageName: "entities",
+			Create:            "tagCreate",
+			Read:              "tags",
+			Delete:            "tagDelete",
+			IDField:           "key",
+		}},
+	}
+
+	g, err := GenerateTerraformForPackage(testTerraformSchema(), pkgConfig)
+	require.NoError(t, err)
+	require.Len(t, g.Resources, 1)
+
+	resource := g.Resources[0]
+	assert.Equal(t, "resourceNewrelicTag", resource.FuncName)
+	assert.Equal(t, "A tag to create", resource.Description)
+	assert.Equal(t, "meta.(*ProviderConfig).NewClient.Entities", resource.Client)
+
+	// Without an update, every attribute forces a new resource.  The recursive
+	// and overridden fields are skipped.
+	assert.Equal(t, []TerraformAttribute{
+		{Name: "key", Description: "The key", Type: "TypeString", Required: true, ForceNew: true},
+		{Name: "values", Type: "TypeList", ElemType: "TypeString", ForceNew: true},
+		{Name: "kind", Type: "TypeString", ForceNew: true, ValidValues: []string{"USER", "SYSTEM"}},
+		{Name: "meta", Type: "TypeList", ForceNew: true, MaxItems: 1, Attributes: []TerraformAttribute{
+			{Name: "priority", Type: "TypeInt"},
+		}},
+		{Name: "guid", Type: "TypeString", Required: true, ForceNew: true},
+	}, resource.Attributes)
+
+	assert.Equal(t, &TerraformOperation{
+		Method:    "TagCreate",
+		Args:      []string{`d.Get("guid").(string)`, "expandTagInput(in)"},
+		UsesInput: true,
+		IDField:   "Key",
+	}, resource.Create)
+	assert.Equal(t, &TerraformOperation{
+		Method:      "GetTags",
+		Args:        []string{`d.Get("guid").(string)`},
+		ReturnSlice: true,
+		Flatten:     "flattenTag",
+	}, resource.Read)
+	assert.Nil(t, resource.Update)
+	assert.Equal(t, []string{`d.Get("guid").(string)`, `d.Get("key").(string)`}, resource.Delete.Args)
+
+	assert.Equal(t, []TerraformConverter{
+		{Name: "expandTagMetaInput", Type: "entities.TagMetaInput", Fields: []TerraformField{
+			{Attribute: "priority", GoName: "Priority", Kind: "value", Type: "int", AttributeType: "int"},
+		}},
+		{Name: "expandTagInput", Type: "entities.TagInput", Fields: []TerraformField{
+			{Attribute: "key", GoName: "Key", Kind: "value", Type: "string", AttributeType: "string"},
+			{Attribute: "values", GoName: "Values", Kind: "values", Type: "string", AttributeType: "string"},
+			{Attribute: "kind", GoName: "Kind", Kind: "value", Type: "entities.TagKind", AttributeType: "string"},
+			{Attribute: "meta", GoName: "Meta", Kind: "block", Func: "expandTagMetaInput"},
+		}},
+	}, g.Expanders)
+	require.Len(t, g.Flatteners, 2)
+	assert.Equal(t, "flattenTagMeta", g.Flatteners[0].Name)
+	assert.Equal(t, "flattenTag", g.Flatteners[1].Name)
+	assert.Len(t, g.Flatteners[1].Fields, 4)
+}
+
+func TestGenerateTerraformForPackage_Errors(t *testing.T) {
+	t.Parallel()
+
+	resource := config.Resource{
+		Name:              "newrelic_tag",
+		InputType:         "TagInput",
+		ClientPackageName: "entities",
+		Create:            "tagCreate",
+		Read:              "tags",
+		Delete:            "tagDelete",
+		IDField:           "key",
+	}
+
+	pkgConfig := &config.PackageConfig{
+		Name:      "newrelic",
+		Mutations: []config.MutationConfig{{Name: "tagCreate"}},
+		Resources: []config.Resource{resource},
+	}
+
+	_, err := GenerateTerraformForPackage(testTerraformSchema(), pkgConfig)
+	assert.EqualError(t, err, "resource newrelic_tag: query endpoint tags is not configured in the package")
+
+	pkgConfig.Queries = []config.Query{{Path: []string{"actor"}, Endpoints: []config.EndpointConfig{{Name: "tags"}}}}
+	_, err = GenerateTerraformForPackage(testTerraformSchema(), pkgConfig)
+	assert.EqualError(t, err, "resource newrelic_tag: mutation tagDelete is not configured in the package")
+
+	resource.InputType = "Tag"
+	pkgConfig.Resources = []config.Resource{resource}
+	_, err = GenerateTerraformForPackage(testTerraformSchema(), pkgConfig)
+	assert.EqualError(t, err, "resource newrelic_tag: Tag is not an input object")
+}
@@ -0,0 +1,85 @@
+package mock
+
+import (
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/newrelic/tutone/internal/schema"
+	"github.com/newrelic/tutone/pkg/fetch"
+)
+
+const (
+	DefaultAddress = "localhost:8080"
+)
+
+var (
+	address      string
+	schemaFile   string
+	fixturesPath string
+)
+
+var Command = &cobra.Command{
+	Use:   "serve-mock",
+	Short: "Serve a mock GraphQL API from the cached schema",
+	Long: `Serve a mock GraphQL API from the cached schema
+
+The serve-mock command loads the cached schema and serves GraphQL
+over HTTP.  Introspection queries are answered from the schema, and
+all other operations receive fake data that conforms to the schema.
+Response fixtures can be provided as a YAML or JSON file keyed by
+operation name, or as a directory with one file per operation.
+`,
+	Example: "tutone serve-mock --config .tutone.yml --fixtures testdata/fixtures",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := Serve(address, schemaFile, fixturesPath); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+// Serve loads the schema and fixtures, then serves the mock API on the
+// address until an error occurs.
+func Serve(address string, schemaFile string, fixturesPath string) error {
+	if schemaFile == "" {
+		schemaFile = viper.GetString("cache.schema_file")
+	}
+
+	if schemaFile == "" {
+		schemaFile = fetch.DefaultSchemaCacheFile
+	}
+
+	s, err := schema.Load(schemaFile)
+	if err != nil {
+		return err
+	}
+
+	var fixtures Fixtures
+	if fixturesPath != "" {
+		fixtures, err = LoadFixtures(fixturesPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	srv, err := NewServer(s, fixtures)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"address":     address,
+		"schema_file": schemaFile,
+		"fixtures":    len(fixtures),
+	}).Info("serving mock GraphQL API")
+
+	return http.ListenAndServe(address, srv)
+}
+
+func init() {
+	Command.Flags().StringVarP(&address, "address", "a", DefaultAddress, "Address to listen on")
+	Command.Flags().StringVarP(&schemaFile, "schema", "s", "", "Schema file to serve, defaults to the cached schema file")
+	Command.Flags().StringVarP(&fixturesPath, "fixtures", "f", "", "Path to a fixtures file, or a directory of fixture files")
+}
@@ -0,0 +1,292 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/newrelic/tutone/internal/schema"
+)
+
+// executor resolves the selection sets of a single operation.  Fields are
+// resolved from a source object when one is available, which is how the
+// introspection fields are answered, and are otherwise filled with fake data
+// that conforms to the schema.
+type executor struct {
+	schema        *ast.Schema
+	doc           *ast.QueryDocument
+	vars          map[string]interface{}
+	introspection map[string]interface{}
+}
+
+// introspectionData returns the cached schema as generic JSON data, so that
+// introspection queries can be answered by walking it with the query's
+// selection sets.
+func introspectionData(s *schema.Schema) (map[string]interface{}, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// execute returns the data for the received operation.
+func (e *executor) execute(op *ast.OperationDefinition) (map[string]interface{}, error) {
+	var root *ast.Definition
+
+	switch op.Operation {
+	case ast.Query:
+		root = e.schema.Query
+	case ast.Mutation:
+		root = e.schema.Mutation
+	default:
+		return nil, fmt.Errorf("operation type %s is not supported", op.Operation)
+	}
+
+	if root == nil {
+		return nil, fmt.Errorf("schema does not define a %s type", op.Operation)
+	}
+
+	return e.executeSelectionSet(root, op.SelectionSet, nil), nil
+}
+
+// executeSelectionSet resolves each of the fields selected on the object.  A
+// nil source means that the object is being faked.
+func (e *executor) executeSelectionSet(obj *ast.Definition, set ast.SelectionSet, source map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+
+	for _, field := range e.collectFields(obj, set) {
+		result[field.Alias] = e.resolveField(obj, field, source)
+	}
+
+	return result
+}
+
+// collectFields flattens the fragments in a selection set that apply to the
+// object, skipping any fields excluded by the @skip and @include directives.
+func (e *executor) collectFields(obj *ast.Definition, set ast.SelectionSet) []*ast.Field {
+	var fields []*ast.Field
+
+	for _, sel := range set {
+		switch s := sel.(type) {
+		case *ast.Field:
+			if e.included(s.Directives) {
+				fields = append(fields, s)
+			}
+		case *ast.InlineFragment:
+			if e.included(s.Directives) && e.applies(obj, s.TypeCondition) {
+				fields = append(fields, e.collectFields(obj, s.SelectionSet)...)
+			}
+		case *ast.FragmentSpread:
+			fragment := e.doc.Fragments.ForName(s.Name)
+			if fragment != nil && e.included(s.Directives) && e.applies(obj, fragment.TypeCondition) {
+				fields = append(fields, e.collectFields(obj, fragment.SelectionSet)...)
+			}
+		}
+	}
+
+	return fields
+}
+
+func (e *executor) included(directives ast.DirectiveList) bool {
+	if d := directives.ForName("skip"); d != nil {
+		if skip, _ := d.ArgumentMap(e.vars)["if"].(bool); skip {
+			return false
+		}
+	}
+
+	if d := directives.ForName("include"); d != nil {
+		if include, _ := d.ArgumentMap(e.vars)["if"].(bool); !include {
+			return false
+		}
+	}
+
+	return true
+}
+
+// applies determines if a fragment with the received type condition should be
+// applied to the object.
+func (e *executor) applies(obj *ast.Definition, typeCondition string) bool {
+	if typeCondition == "" || typeCondition == obj.Name {
+		return true
+	}
+
+	condition := e.schema.Types[typeCondition]
+	if condition == nil {
+		return false
+	}
+
+	for _, p := range e.schema.GetPossibleTypes(condition) {
+		if p.Name == obj.Name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (e *executor) resolveField(obj *ast.Definition, field *ast.Field, source map[string]interface{}) interface{} {
+	if field.Name == "__typename" {
+		return obj.Name
+	}
+
+	if obj == e.schema.Query {
+		switch field.Name {
+		case "__schema":
+			return e.completeValue(field.Definition.Type, field, e.introspection, false)
+		case "__type":
+			name, _ := field.ArgumentMap(e.vars)["name"].(string)
+			return e.completeValue(field.Definition.Type, field, e.introspectionType(name), false)
+		}
+	}
+
+	if field.Definition == nil {
+		return nil
+	}
+
+	if source == nil {
+		return e.completeValue(field.Definition.Type, field, nil, true)
+	}
+
+	return e.completeValue(field.Definition.Type, field, source[field.Name], false)
+}
+
+// completeValue shapes the value according to the type and the selection set
+// of the field, or fakes the value when requested.
+func (e *executor) completeValue(t *ast.Type, field *ast.Field, value interface{}, fake bool) interface{} {
+	if t.Elem != nil {
+		if fake {
+			return []interface{}{e.completeValue(t.Elem, field, nil, true)}
+		}
+
+		items, ok := value.([]interface{})
+		if !ok {
+			if t.NonNull {
+				return []interface{}{}
+			}
+
+			return nil
+		}
+
+		result := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			result = append(result, e.completeValue(t.Elem, field, item, false))
+		}
+
+		return result
+	}
+
+	def := e.schema.Types[t.NamedType]
+	if def == nil {
+		return nil
+	}
+
+	switch def.Kind {
+	case ast.Scalar, ast.Enum:
+		if fake {
+			return fakeLeafValue(def, field)
+		}
+
+		if value == nil && t.NonNull {
+			return zeroLeafValue(def)
+		}
+
+		return value
+	default:
+		var source map[string]interface{}
+
+		if !fake {
+			var ok bool
+			if source, ok = value.(map[string]interface{}); !ok {
+				return nil
+			}
+		}
+
+		return e.executeSelectionSet(e.concreteType(def, source), field.SelectionSet, source)
+	}
+}
+
+// concreteType returns the object type used to resolve an abstract type.  The
+// __typename of the source is used when available, otherwise the first
+// possible type by name.
+func (e *executor) concreteType(def *ast.Definition, source map[string]interface{}) *ast.Definition {
+	if !def.IsAbstractType() {
+		return def
+	}
+
+	if typeName, ok := source["__typename"].(string); ok {
+		if t := e.schema.Types[typeName]; t != nil {
+			return t
+		}
+	}
+
+	possibleTypes := e.schema.GetPossibleTypes(def)
+	if len(possibleTypes) == 0 {
+		return def
+	}
+
+	names := make([]string, 0, len(possibleTypes))
+	for _, p := range possibleTypes {
+		names = append(names, p.Name)
+	}
+	sort.Strings(names)
+
+	return e.schema.Types[names[0]]
+}
+
+func (e *executor) introspectionType(name string) interface{} {
+	types, _ := e.introspection["types"].([]interface{})
+
+	for _, t := range types {
+		if m, ok := t.(map[string]interface{}); ok && m["name"] == name {
+			return m
+		}
+	}
+
+	return nil
+}
+
+// fakeLeafValue returns a predictable value for a scalar or enum, so that
+// responses are stable across requests.
+func fakeLeafValue(def *ast.Definition, field *ast.Field) interface{} {
+	switch def.Kind {
+	case ast.Enum:
+		if len(def.EnumValues) > 0 {
+			return def.EnumValues[0].Name
+		}
+
+		return nil
+	}
+
+	switch def.Name {
+	case "Int":
+		return 1
+	case "Float":
+		return 1.5
+	case "Boolean":
+		return true
+	case "ID":
+		return "1"
+	default:
+		return field.Name
+	}
+}
+
+func zeroLeafValue(def *ast.Definition) interface{} {
+	switch def.Name {
+	case "Int", "Float":
+		return 0
+	case "Boolean":
+		return false
+	default:
+		return ""
+	}
+}
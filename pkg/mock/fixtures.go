@@ -0,0 +1,141 @@
+package mock
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vektah/gqlparser/v2/ast"
+	"gopkg.in/yaml.v2"
+)
+
+// Fixtures are canned response bodies keyed by operation name.  Anonymous
+// operations are matched by the name of their first root field.
+type Fixtures map[string]interface{}
+
+// Lookup returns the fixture for the operation, if any.
+func (f Fixtures) Lookup(op *ast.OperationDefinition) (interface{}, bool) {
+	if f == nil {
+		return nil, false
+	}
+
+	name := op.Name
+	if name == "" {
+		for _, sel := range op.SelectionSet {
+			if field, ok := sel.(*ast.Field); ok {
+				name = field.Name
+				break
+			}
+		}
+	}
+
+	fixture, ok := f[name]
+
+	return fixture, ok
+}
+
+// LoadFixtures reads fixtures from a YAML or JSON file, which contains a map of
+// operation name to response body, or from a directory where each file
+// contains a single response body named for the operation, i.e.
+// GetEntity.yml.
+func LoadFixtures(path string) (Fixtures, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		var fixtures map[string]interface{}
+		if err := readFixtureFile(path, &fixtures); err != nil {
+			return nil, err
+		}
+
+		result := make(Fixtures, len(fixtures))
+		for name, fixture := range fixtures {
+			result[name] = normalize(fixture)
+		}
+
+		return result, nil
+	}
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fixtures := make(Fixtures)
+
+	for _, file := range files {
+		ext := filepath.Ext(file.Name())
+		if file.IsDir() || !isFixtureExt(ext) {
+			continue
+		}
+
+		var fixture interface{}
+		if err := readFixtureFile(filepath.Join(path, file.Name()), &fixture); err != nil {
+			return nil, err
+		}
+
+		fixtures[strings.TrimSuffix(file.Name(), ext)] = normalize(fixture)
+	}
+
+	return fixtures, nil
+}
+
+func readFixtureFile(file string, out interface{}) error {
+	log.WithFields(log.Fields{
+		"file": file,
+	}).Debug("loading fixture")
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	// JSON is a subset of YAML, so a single decoder handles both formats.
+	if err := yaml.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("unable to parse fixture %s: %s", file, err)
+	}
+
+	return nil
+}
+
+func isFixtureExt(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".json", ".yaml", ".yml":
+		return true
+	default:
+		return false
+	}
+}
+
+// normalize converts the maps decoded from YAML into maps with string keys,
+// which can be encoded as JSON.
+func normalize(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			m[fmt.Sprint(k)] = normalize(item)
+		}
+
+		return m
+	case map[string]interface{}:
+		for k, item := range value {
+			value[k] = normalize(item)
+		}
+
+		return value
+	case []interface{}:
+		for i, item := range value {
+			value[i] = normalize(item)
+		}
+
+		return value
+	default:
+		return v
+	}
+}
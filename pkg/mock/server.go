@@ -0,0 +1,149 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/validator"
+
+	"github.com/newrelic/tutone/internal/schema"
+)
+
+// Request is the body of a GraphQL request sent over HTTP.
+type Request struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+}
+
+// Response is the body of a GraphQL response.
+type Response struct {
+	Data   interface{}   `json:"data,omitempty"`
+	Errors gqlerror.List `json:"errors,omitempty"`
+}
+
+// Server answers GraphQL requests using the cached schema.  Introspection is
+// answered from the schema itself, any operation with a fixture receives the
+// fixture as the response body, and all other operations receive fake data
+// that conforms to the schema.
+type Server struct {
+	schema        *ast.Schema
+	introspection map[string]interface{}
+	fixtures      Fixtures
+}
+
+// NewServer returns a Server for the received schema and fixtures, which may
+// be nil.
+func NewServer(s *schema.Schema, fixtures Fixtures) (*Server, error) {
+	astSchema, err := s.AST()
+	if err != nil {
+		return nil, err
+	}
+
+	introspection, err := introspectionData(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		schema:        astSchema,
+		introspection: introspection,
+		fixtures:      fixtures,
+	}, nil
+}
+
+// ServeHTTP handles a single GraphQL request.
+func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "only POST requests are supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Numbers are decoded as json.Number, which is accepted for both Int and
+	// Float variables.
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber()
+
+	var req Request
+	if err := dec.Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("unable to decode request: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"operation": req.OperationName,
+	}).Debug("handling request")
+
+	body := srv.Execute(req)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Errorf("unable to write response: %s", err)
+	}
+}
+
+// Execute returns the response body for the request, which is either a
+// fixture or a Response.
+func (srv *Server) Execute(req Request) interface{} {
+	doc, errs := gqlparser.LoadQuery(srv.schema, req.Query)
+	if len(errs) > 0 {
+		return Response{Errors: errs}
+	}
+
+	op, err := operation(doc, req.OperationName)
+	if err != nil {
+		return Response{Errors: gqlerror.List{gqlerror.Errorf("%s", err)}}
+	}
+
+	if fixture, ok := srv.fixtures.Lookup(op); ok {
+		log.WithFields(log.Fields{
+			"operation": op.Name,
+		}).Debug("using fixture")
+
+		return fixture
+	}
+
+	vars, gqlErr := validator.VariableValues(srv.schema, op, req.Variables)
+	if gqlErr != nil {
+		return Response{Errors: gqlerror.List{gqlErr}}
+	}
+
+	e := executor{
+		schema:        srv.schema,
+		doc:           doc,
+		vars:          vars,
+		introspection: srv.introspection,
+	}
+
+	data, err := e.execute(op)
+	if err != nil {
+		return Response{Errors: gqlerror.List{gqlerror.Errorf("%s", err)}}
+	}
+
+	return Response{Data: data}
+}
+
+// operation selects the operation to execute from the document.  The name is
+// only required when the document contains several operations.
+func operation(doc *ast.QueryDocument, name string) (*ast.OperationDefinition, error) {
+	if name != "" {
+		op := doc.Operations.ForName(name)
+		if op == nil {
+			return nil, fmt.Errorf("operation %s not found", name)
+		}
+
+		return op, nil
+	}
+
+	if len(doc.Operations) != 1 {
+		return nil, fmt.Errorf("operationName is required for a document with %d operations", len(doc.Operations))
+	}
+
+	return doc.Operations[0], nil
+}
@@ -0,0 +1,248 @@
+//go:build unit
+// +build unit
+
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/tutone/internal/schema"
+	"github.com/newrelic/tutone/pkg/fetch"
+)
+
+func testMockSchema() *schema.Schema {
+	nonNull := func(r schema.TypeRef) schema.TypeRef {
+		return schema.TypeRef{Kind: schema.KindNonNull, OfType: &r}
+	}
+
+	queryType := &schema.Type{
+		Name: "RootQueryType",
+		Kind: schema.KindObject,
+		Fields: []schema.Field{
+			{
+				Name: "entity",
+				Type: schema.TypeRef{Name: "Entity", Kind: schema.KindInterface},
+				Args: []schema.Field{
+					{Name: "guid", Type: nonNull(schema.TypeRef{Name: "ID", Kind: schema.KindScalar})},
+				},
+			},
+		},
+	}
+
+	entityFields := []schema.Field{
+		{Name: "guid", Type: nonNull(schema.TypeRef{Name: "ID", Kind: schema.KindScalar})},
+		{Name: "name", Type: schema.TypeRef{Name: "String", Kind: schema.KindScalar}},
+		{Name: "tags", Type: schema.TypeRef{Kind: schema.KindList, OfType: &schema.TypeRef{Name: "String", Kind: schema.KindScalar}}},
+		{Name: "type", Type: schema.TypeRef{Name: "EntityType", Kind: schema.KindENUM}},
+	}
+
+	apmFields := append([]schema.Field{
+		{Name: "language", Type: schema.TypeRef{Name: "String", Kind: schema.KindScalar}},
+		{Name: "reporting", Type: schema.TypeRef{Name: "Boolean", Kind: schema.KindScalar}},
+	}, entityFields...)
+
+	return &schema.Schema{
+		QueryType: queryType,
+		Types: []*schema.Type{
+			queryType,
+			{
+				Name:   "Entity",
+				Kind:   schema.KindInterface,
+				Fields: entityFields,
+				PossibleTypes: []schema.TypeRef{
+					{Name: "BrowserEntity", Kind: schema.KindObject},
+					{Name: "ApmEntity", Kind: schema.KindObject},
+				},
+			},
+			{
+				Name:       "ApmEntity",
+				Kind:       schema.KindObject,
+				Fields:     apmFields,
+				Interfaces: []schema.TypeRef{{Name: "Entity", Kind: schema.KindInterface}},
+			},
+			{
+				Name:       "BrowserEntity",
+				Kind:       schema.KindObject,
+				Fields:     entityFields,
+				Interfaces: []schema.TypeRef{{Name: "Entity", Kind: schema.KindInterface}},
+			},
+			{
+				Name: "EntityType",
+				Kind: schema.KindENUM,
+				EnumValues: []schema.EnumValue{
+					{Name: "APM"},
+					{Name: "BROWSER"},
+				},
+			},
+			{Name: "Boolean", Kind: schema.KindScalar},
+			{Name: "ID", Kind: schema.KindScalar},
+			{Name: "String", Kind: schema.KindScalar},
+		},
+	}
+}
+
+func testServer(t *testing.T, fixtures Fixtures) *httptest.Server {
+	srv, err := NewServer(testMockSchema(), fixtures)
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func post(t *testing.T, url string, req Request) map[string]interface{} {
+	body, err := json.Marshal(req)
+	require.NoError(t, err)
+
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+
+	return result
+}
+
+func TestServer_FakeData(t *testing.T) {
+	t.Parallel()
+
+	ts := testServer(t, nil)
+
+	result := post(t, ts.URL, Request{
+		Query: `query GetEntity($guid: ID!, $withTags: Boolean!) {
+	entity(guid: $guid) {
+		__typename
+		...EntityFields
+		label: name
+		tags @include(if: $withTags)
+		... on ApmEntity { language reporting }
+		... on BrowserEntity { type }
+	}
+}
+fragment EntityFields on Entity { guid type }`,
+		Variables: map[string]interface{}{"guid": "abc", "withTags": false},
+	})
+
+	expected := map[string]interface{}{
+		"data": map[string]interface{}{
+			"entity": map[string]interface{}{
+				"__typename": "ApmEntity",
+				"guid":       "1",
+				"type":       "APM",
+				"label":      "name",
+				"language":   "language",
+				"reporting":  true,
+			},
+		},
+	}
+
+	assert.Equal(t, expected, result)
+}
+
+func TestServer_Fixtures(t *testing.T) {
+	t.Parallel()
+
+	fixtures := Fixtures{
+		"GetEntity": map[string]interface{}{
+			"data": map[string]interface{}{"entity": nil},
+		},
+		"entity": map[string]interface{}{
+			"errors": []interface{}{map[string]interface{}{"message": "not found"}},
+		},
+	}
+
+	ts := testServer(t, fixtures)
+
+	result := post(t, ts.URL, Request{Query: `query GetEntity { entity(guid: "1") { name } }`})
+	assert.Equal(t, fixtures["GetEntity"], result)
+
+	// Anonymous operations are matched by the first root field
+	result = post(t, ts.URL, Request{Query: `{ entity(guid: "1") { name } }`})
+	assert.Equal(t, fixtures["entity"], result)
+}
+
+func TestServer_Errors(t *testing.T) {
+	t.Parallel()
+
+	ts := testServer(t, nil)
+
+	result := post(t, ts.URL, Request{Query: `{ entity { unknown } }`})
+	assert.Nil(t, result["data"])
+	assert.Len(t, result["errors"], 2)
+
+	result = post(t, ts.URL, Request{Query: `query A { __typename } query B { __typename }`})
+	require.Len(t, result["errors"], 1)
+	assert.Equal(t, "operationName is required for a document with 2 operations", result["errors"].([]interface{})[0].(map[string]interface{})["message"])
+
+	result = post(t, ts.URL, Request{Query: `query A { __typename } query B { __typename }`, OperationName: "B"})
+	assert.Equal(t, map[string]interface{}{"__typename": "RootQueryType"}, result["data"])
+
+	resp, err := http.Get(ts.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+// The mock server stands in for a real GraphQL server when fetching the schema.
+func TestServer_Fetch(t *testing.T) {
+	t.Parallel()
+
+	ts := testServer(t, nil)
+
+	e := fetch.NewEndpoint()
+	e.URL = ts.URL
+
+	s, err := e.Fetch()
+	require.NoError(t, err)
+
+	require.NotNil(t, s.QueryType)
+	assert.Equal(t, "RootQueryType", s.QueryType.Name)
+	assert.Nil(t, s.MutationType)
+
+	assert.Equal(t, testMockSchema().SDL(), s.SDL())
+}
+
+func TestLoadFixtures(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "tutone-fixtures")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	yml := "data:\n  entity:\n    name: test\n"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "GetEntity.yml"), []byte(yml), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("ignored"), 0644))
+
+	fixtures, err := LoadFixtures(dir)
+	require.NoError(t, err)
+
+	expected := Fixtures{
+		"GetEntity": map[string]interface{}{
+			"data": map[string]interface{}{
+				"entity": map[string]interface{}{"name": "test"},
+			},
+		},
+	}
+	assert.Equal(t, expected, fixtures)
+
+	file := filepath.Join(dir, "fixtures.json")
+	require.NoError(t, ioutil.WriteFile(file, []byte(`{"GetEntity": {"data": {"entity": {"name": "test"}}}}`), 0644))
+
+	fixtures, err = LoadFixtures(file)
+	require.NoError(t, err)
+	assert.Equal(t, expected, fixtures)
+}
@@ -0,0 +1,102 @@
+// Package plugin is the SDK for writing tutone generator plugins.
+//
+// A plugin is an executable that is configured as the plugin of a generator.
+// For each package using the generator, tutone runs the plugin with a Request
+// encoded as JSON on stdin, and the plugin responds with the files to write,
+// as a Response encoded as JSON on stdout.  Go files are formatted, and all of
+// the files are written exactly like the output of the built-in generators.
+// Anything written to stderr is passed through to the user.
+//
+//	func main() {
+//		plugin.Main(func(req *plugin.Request) ([]plugin.File, error) {
+//			return []plugin.File{{Name: "doc.go", Content: "package " + req.Package.Name}}, nil
+//		})
+//	}
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/newrelic/tutone/internal/config"
+	"github.com/newrelic/tutone/internal/schema"
+)
+
+// ProtocolVersion is the version of the Request and Response.  It changes when
+// either does in a way that is not backwards compatible.
+const ProtocolVersion = 1
+
+// The types received by plugins, which are the same as used by the built-in
+// generators.  The configuration types are encoded using their Go field names.
+type (
+	Schema          = schema.Schema
+	Type            = schema.Type
+	Field           = schema.Field
+	GeneratorConfig = config.GeneratorConfig
+	PackageConfig   = config.PackageConfig
+)
+
+// Request is sent to the plugin on stdin.
+type Request struct {
+	Version   int              `json:"version"`
+	Schema    *Schema          `json:"schema"`
+	Generator *GeneratorConfig `json:"generator"`
+	Package   *PackageConfig   `json:"package"`
+}
+
+// File is a file to write, with a Name relative to the path of the package.
+type File struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// Response is returned by the plugin on stdout.
+type Response struct {
+	Files []File `json:"files,omitempty"`
+	// Error is the reason the plugin failed, in which case it also exits
+	// non-zero.
+	Error string `json:"error,omitempty"`
+}
+
+// GenerateFunc returns the files generated for the package in the Request.
+type GenerateFunc func(*Request) ([]File, error)
+
+// Main serves a single Request from stdin, writing the Response to stdout, and
+// exits non-zero when generation fails.  It is intended to be called from the
+// main function of a plugin.
+func Main(generate GenerateFunc) {
+	if err := Serve(os.Stdin, os.Stdout, generate); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// Serve reads a Request from r, and writes the Response of the generate func
+// to w.  The returned error is also sent in the Response.
+func Serve(r io.Reader, w io.Writer, generate GenerateFunc) error {
+	var files []File
+
+	var req Request
+	err := json.NewDecoder(r).Decode(&req)
+	if err != nil {
+		err = fmt.Errorf("failed to decode request: %s", err)
+	} else if req.Version != ProtocolVersion {
+		err = fmt.Errorf("unsupported protocol version %d, expected %d", req.Version, ProtocolVersion)
+	} else {
+		files, err = generate(&req)
+	}
+
+	resp := Response{Files: files}
+	if err != nil {
+		resp.Files = nil
+		resp.Error = err.Error()
+	}
+
+	if encodeErr := json.NewEncoder(w).Encode(resp); encodeErr != nil {
+		return encodeErr
+	}
+
+	return err
+}
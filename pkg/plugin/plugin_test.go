@@ -0,0 +1,73 @@
+//go:build unit
+// +build unit
+
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServe(t *testing.T) {
+	t.Parallel()
+
+	req, err := json.Marshal(Request{
+		Version:   ProtocolVersion,
+		Schema:    &Schema{Types: []*Type{{Name: "AlertsPolicy"}}},
+		Generator: &GeneratorConfig{Name: "docs", Plugin: "tutone-docs"},
+		Package:   &PackageConfig{Name: "alerts"},
+	})
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	err = Serve(bytes.NewReader(req), &out, func(r *Request) ([]File, error) {
+		return []File{{Name: r.Package.Name + ".md", Content: r.Schema.Types[0].Name}}, nil
+	})
+	require.NoError(t, err)
+
+	var resp Response
+	require.NoError(t, json.Unmarshal(out.Bytes(), &resp))
+	assert.Equal(t, Response{Files: []File{{Name: "alerts.md", Content: "AlertsPolicy"}}}, resp)
+}
+
+func TestServe_Error(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		Request  string
+		Error    string
+		Generate GenerateFunc
+	}{
+		"invalid request": {
+			Request: "{",
+			Error:   "failed to decode request: unexpected EOF",
+		},
+		"unsupported version": {
+			Request: `{"version": 0}`,
+			Error:   "unsupported protocol version 0, expected 1",
+		},
+		"failed generate": {
+			Request: `{"version": 1}`,
+			Error:   "failed",
+			Generate: func(*Request) ([]File, error) {
+				return []File{{Name: "ignored.go"}}, errors.New("failed")
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		var out bytes.Buffer
+		err := Serve(strings.NewReader(tc.Request), &out, tc.Generate)
+		assert.EqualError(t, err, tc.Error, name)
+
+		var resp Response
+		require.NoError(t, json.Unmarshal(out.Bytes(), &resp), name)
+		assert.Equal(t, Response{Error: tc.Error}, resp, name)
+	}
+}
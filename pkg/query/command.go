@@ -0,0 +1,43 @@
+package query
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var options QueryOptions
+
+var Command = &cobra.Command{
+	Use:   "query",
+	Short: "Execute a GraphQL operation against the configured endpoint",
+	Long: `Execute a GraphQL operation against the configured endpoint
+
+The query command sends a single operation to the endpoint in your
+.tutone.yml configuration file, using the configured authentication.
+The operation is read from a file, or built from the cached schema for
+a mutation or query path exactly as tutone would generate it, using
+the matching package configuration when there is one.  The response,
+including any GraphQL errors, is printed as formatted JSON.
+`,
+	Example: `tutone query --mutation alertsPolicyCreate --var accountId=1 --var 'policy={"name":"test"}'
+tutone query --path actor.account --var accountid=1 --depth 1
+tutone query --file operation.graphql --variables @variables.json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := Query(options, cmd.OutOrStdout()); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	Command.Flags().StringVarP(&options.File, "file", "f", "", "File containing the GraphQL operation to send")
+	Command.Flags().StringVar(&options.OperationName, "operation", "", "Name of the operation to send when the file contains several")
+	Command.Flags().StringVarP(&options.Mutation, "mutation", "m", "", "Name of a mutation to build the operation for")
+	Command.Flags().StringVar(&options.Path, "path", "", "Dot separated path of fields to a query endpoint, i.e. actor.cloud.linkedAccounts")
+	Command.Flags().IntVar(&options.Depth, "depth", 0, "Maximum depth of the fields selected by a built operation, overrides the configuration")
+	Command.Flags().StringVar(&options.Variables, "variables", "", "JSON object of variables, or @file to read them from a file")
+	Command.Flags().StringArrayVar(&options.Vars, "var", nil, "Variable in the format name=value, values are decoded as JSON when possible")
+	Command.Flags().StringVarP(&options.Endpoint, "endpoint", "e", "", "GraphQL endpoint, defaults to the configured endpoint")
+	Command.Flags().StringVarP(&options.SchemaFile, "schema", "s", "", "Schema file to build operations from, defaults to the cached schema file")
+	Command.Flags().BoolVar(&options.DryRun, "dry-run", false, "Print the operation and variables without sending them")
+}
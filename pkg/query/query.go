@@ -0,0 +1,355 @@
+package query
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+
+	"github.com/newrelic/tutone/internal/config"
+	"github.com/newrelic/tutone/internal/schema"
+	"github.com/newrelic/tutone/pkg/fetch"
+)
+
+// DefaultQueryFieldDepth is the depth of the fields selected by an operation
+// that isn't found in the configuration file.
+const DefaultQueryFieldDepth = 2
+
+type QueryOptions struct {
+	// File is the path to a file containing the operation to send.
+	File string
+	// OperationName selects the operation when the File contains several.
+	OperationName string
+	// Mutation is the name of a mutation to build the operation for.
+	Mutation string
+	// Path is the dot separated path of fields to a query endpoint, i.e. actor.cloud.linkedAccounts
+	Path string
+	// Depth overrides the maximum depth of the fields selected by a built operation.
+	Depth int
+	// Variables is a JSON object of variables, or the path of a file containing one prefixed with @.
+	Variables string
+	// Vars are individual name=value variables, which take precedence over the Variables.
+	Vars []string
+	// Endpoint overrides the endpoint from the configuration file.
+	Endpoint string
+	// SchemaFile overrides the cached schema file from the configuration file.
+	SchemaFile string
+	// DryRun prints the operation and variables without sending them.
+	DryRun bool
+}
+
+// Query builds the operation, sends it to the configured endpoint and writes
+// the formatted response to out.  An error is returned when the response
+// contains GraphQL errors.
+func Query(options QueryOptions, out io.Writer) error {
+	operation, err := operationForOptions(options)
+	if err != nil {
+		return err
+	}
+
+	variables, err := ParseVariables(options.Variables, options.Vars)
+	if err != nil {
+		return err
+	}
+
+	if options.DryRun {
+		fmt.Fprintln(out, operation)
+
+		if len(variables) > 0 {
+			return writeJSON(out, variables)
+		}
+
+		return nil
+	}
+
+	e := fetch.NewEndpoint()
+	e.URL = options.Endpoint
+	if e.URL == "" {
+		e.URL = viper.GetString("endpoint")
+	}
+	e.Auth.Disable = viper.GetBool("auth.disable")
+	e.Auth.Header = viper.GetString("auth.header")
+	e.Auth.APIKey = os.Getenv(viper.GetString("auth.api_key_env_var"))
+
+	log.WithFields(log.Fields{
+		"endpoint": e.URL,
+	}).Debug("sending operation")
+
+	body, err := e.Query(fetch.GraphqlQuery{
+		Query:         operation,
+		Variables:     variables,
+		OperationName: options.OperationName,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeResponse(out, body)
+}
+
+// operationForOptions reads the operation from a file, or builds it from the
+// schema and configuration.
+func operationForOptions(options QueryOptions) (string, error) {
+	selectors := 0
+	for _, s := range []string{options.File, options.Mutation, options.Path} {
+		if s != "" {
+			selectors++
+		}
+	}
+
+	if selectors != 1 {
+		return "", errors.New("exactly one of a file, mutation or path is required")
+	}
+
+	if options.File != "" {
+		operation, err := ioutil.ReadFile(options.File)
+		if err != nil {
+			return "", err
+		}
+
+		return string(operation), nil
+	}
+
+	schemaFile := options.SchemaFile
+	if schemaFile == "" {
+		schemaFile = viper.GetString("cache.schema_file")
+	}
+
+	if schemaFile == "" {
+		schemaFile = fetch.DefaultSchemaCacheFile
+	}
+
+	s, err := schema.Load(schemaFile)
+	if err != nil {
+		return "", err
+	}
+
+	// The configuration file is optional, and only used to build the operation
+	// the same way as the generators.
+	var cfg *config.Config
+	if file := viper.ConfigFileUsed(); file != "" {
+		if _, statErr := os.Stat(file); statErr == nil {
+			cfg, err = config.LoadConfig(file)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if options.Mutation != "" {
+		return BuildMutation(s, cfg, options.Mutation, options.Depth)
+	}
+
+	return BuildQuery(s, cfg, options.Path, options.Depth)
+}
+
+// BuildMutation returns the operation for the named mutation, using the
+// mutation configuration from the first package that includes it.
+func BuildMutation(s *schema.Schema, cfg *config.Config, name string, depth int) (string, error) {
+	if s.MutationType == nil {
+		return "", errors.New("schema has no mutations")
+	}
+
+	field, err := s.LookupMutationByName(name)
+	if err != nil {
+		return "", err
+	}
+
+	mutationConfig, pkgConfig := findMutationConfig(s, cfg, name)
+	if depth > 0 {
+		mutationConfig.MaxQueryFieldDepth = depth
+	}
+
+	if pkgConfig != nil && pkgConfig.QueryFragments {
+		fragments := schema.NewFragmentSet()
+		operation, defs := s.GetQueryStringForMutationWithFragments(field, mutationConfig, fragments)
+
+		return withFragments(operation, defs), nil
+	}
+
+	return s.GetQueryStringForMutation(field, mutationConfig), nil
+}
+
+// BuildQuery returns the operation for the endpoint at the end of the dot
+// separated path, using the endpoint configuration from the first package that
+// includes it.
+func BuildQuery(s *schema.Schema, cfg *config.Config, path string, depth int) (string, error) {
+	fieldPath := strings.Split(path, ".")
+	if len(fieldPath) < 2 {
+		return "", fmt.Errorf("path %s must include at least one field before the endpoint", path)
+	}
+
+	endpointName := fieldPath[len(fieldPath)-1]
+	fieldPath = fieldPath[:len(fieldPath)-1]
+
+	typePath, err := s.LookupQueryTypesByFieldPath(fieldPath)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err = typePath[len(typePath)-1].GetField(endpointName); err != nil {
+		return "", err
+	}
+
+	endpoint, pkgConfig := findEndpointConfig(cfg, fieldPath, endpointName)
+	if depth > 0 {
+		endpoint.MaxQueryFieldDepth = depth
+	}
+
+	if pkgConfig != nil && pkgConfig.QueryFragments {
+		fragments := schema.NewFragmentSet()
+		operation, defs := s.GetQueryStringForEndpointWithFragments(typePath, fieldPath, endpoint, fragments)
+
+		return withFragments(operation, defs), nil
+	}
+
+	return s.GetQueryStringForEndpoint(typePath, fieldPath, endpoint), nil
+}
+
+func findMutationConfig(s *schema.Schema, cfg *config.Config, name string) (config.MutationConfig, *config.PackageConfig) {
+	if cfg != nil {
+		for i, pkgConfig := range cfg.Packages {
+			for _, m := range pkgConfig.Mutations {
+				for _, f := range s.LookupMutationsByPattern(m.Name) {
+					if f.Name == name {
+						m.Name = name
+						return m, &cfg.Packages[i]
+					}
+				}
+			}
+		}
+	}
+
+	return config.MutationConfig{
+		Name:               name,
+		MaxQueryFieldDepth: DefaultQueryFieldDepth,
+	}, nil
+}
+
+func findEndpointConfig(cfg *config.Config, fieldPath []string, name string) (config.EndpointConfig, *config.PackageConfig) {
+	if cfg != nil {
+		for i, pkgConfig := range cfg.Packages {
+			for _, q := range pkgConfig.Queries {
+				if strings.Join(q.Path, ".") != strings.Join(fieldPath, ".") {
+					continue
+				}
+
+				for _, endpoint := range q.Endpoints {
+					if endpoint.Name == name {
+						return endpoint, &cfg.Packages[i]
+					}
+				}
+			}
+		}
+	}
+
+	return config.EndpointConfig{
+		Name:               name,
+		MaxQueryFieldDepth: DefaultQueryFieldDepth,
+	}, nil
+}
+
+func withFragments(operation string, fragments []*schema.Fragment) string {
+	for _, f := range fragments {
+		operation += "\n" + f.Definition()
+	}
+
+	return operation
+}
+
+// ParseVariables merges the JSON object of variables with the name=value
+// variables.  A value is decoded as JSON when possible, and otherwise used as
+// a string, so that both --var count=10 and --var name=test work as expected.
+func ParseVariables(variables string, vars []string) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+
+	if strings.HasPrefix(variables, "@") {
+		data, err := ioutil.ReadFile(strings.TrimPrefix(variables, "@"))
+		if err != nil {
+			return nil, err
+		}
+
+		variables = string(data)
+	}
+
+	if strings.TrimSpace(variables) != "" {
+		if err := json.Unmarshal([]byte(variables), &result); err != nil {
+			return nil, fmt.Errorf("unable to parse variables: %s", err)
+		}
+	}
+
+	for _, v := range vars {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("variable %q must be in the format name=value", v)
+		}
+
+		var value interface{}
+		if err := json.Unmarshal([]byte(parts[1]), &value); err != nil {
+			value = parts[1]
+		}
+
+		result[parts[0]] = value
+	}
+
+	return result, nil
+}
+
+// writeResponse writes the indented response body, returning the GraphQL
+// errors in the response as an error.
+func writeResponse(out io.Writer, body []byte) error {
+	var response struct {
+		Errors []struct {
+			Message string        `json:"message"`
+			Path    []interface{} `json:"path,omitempty"`
+		} `json:"errors"`
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("unable to parse response: %s", err)
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, body, "", "  "); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, strings.TrimSpace(indented.String()))
+
+	if len(response.Errors) == 0 {
+		return nil
+	}
+
+	messages := make([]string, 0, len(response.Errors))
+	for _, e := range response.Errors {
+		message := e.Message
+		if len(e.Path) > 0 {
+			path := make([]string, 0, len(e.Path))
+			for _, p := range e.Path {
+				path = append(path, fmt.Sprint(p))
+			}
+			message = fmt.Sprintf("%s: %s", strings.Join(path, "."), message)
+		}
+		messages = append(messages, message)
+	}
+
+	return fmt.Errorf("%d GraphQL error(s) returned: %s", len(messages), strings.Join(messages, "; "))
+}
+
+func writeJSON(out io.Writer, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, string(data))
+
+	return nil
+}
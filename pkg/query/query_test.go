@@ -0,0 +1,176 @@
+//go:build unit
+// +build unit
+
+package query
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/tutone/internal/config"
+	"github.com/newrelic/tutone/internal/schema"
+	"github.com/newrelic/tutone/pkg/mock"
+)
+
+func testQuerySchema() *schema.Schema {
+	nonNull := func(r schema.TypeRef) schema.TypeRef {
+		return schema.TypeRef{Kind: schema.KindNonNull, OfType: &r}
+	}
+
+	queryType := &schema.Type{
+		Name: "RootQueryType",
+		Kind: schema.KindObject,
+		Fields: []schema.Field{
+			{Name: "actor", Type: schema.TypeRef{Name: "Actor", Kind: schema.KindObject}},
+		},
+	}
+
+	mutationType := &schema.Type{
+		Name: "RootMutationType",
+		Kind: schema.KindObject,
+		Fields: []schema.Field{
+			{
+				Name: "userRename",
+				Type: schema.TypeRef{Name: "User", Kind: schema.KindObject},
+				Args: []schema.Field{
+					{Name: "name", Type: nonNull(schema.TypeRef{Name: "String", Kind: schema.KindScalar})},
+				},
+			},
+		},
+	}
+
+	return &schema.Schema{
+		QueryType:    queryType,
+		MutationType: mutationType,
+		Types: []*schema.Type{
+			queryType,
+			mutationType,
+			{
+				Name: "Actor",
+				Kind: schema.KindObject,
+				Fields: []schema.Field{
+					{
+						Name: "user",
+						Type: schema.TypeRef{Name: "User", Kind: schema.KindObject},
+						Args: []schema.Field{
+							{Name: "id", Type: nonNull(schema.TypeRef{Name: "Int", Kind: schema.KindScalar})},
+						},
+					},
+				},
+			},
+			{
+				Name: "User",
+				Kind: schema.KindObject,
+				Fields: []schema.Field{
+					{Name: "name", Type: schema.TypeRef{Name: "String", Kind: schema.KindScalar}},
+					{Name: "manager", Type: schema.TypeRef{Name: "User", Kind: schema.KindObject}},
+				},
+			},
+			{Name: "Int", Kind: schema.KindScalar},
+			{Name: "String", Kind: schema.KindScalar},
+		},
+	}
+}
+
+func TestBuildMutation(t *testing.T) {
+	t.Parallel()
+
+	s := testQuerySchema()
+
+	result, err := BuildMutation(s, nil, "userRename", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "mutation(\n\t$name: String!,\n) { userRename(\n\tname: $name,\n) {\n\tmanager {\n\t\tname\n\t}\n\tname\n} }", result)
+
+	// The package configuration is used when the mutation is included
+	cfg := &config.Config{
+		Packages: []config.PackageConfig{
+			{
+				Name:           "users",
+				QueryFragments: true,
+				Mutations:      []config.MutationConfig{{Name: "user.*", MaxQueryFieldDepth: 2}},
+			},
+		},
+	}
+
+	result, err = BuildMutation(s, cfg, "userRename", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "mutation(\n\t$name: String!,\n) { userRename(\n\tname: $name,\n) {\n\tmanager {\n\t\t...UserFields2\n\t}\n\tname\n} }\nfragment UserFields on User {\n\tname\n}\nfragment UserFields2 on User {\n\tmanager {\n\t\t...UserFields\n\t}\n\tname\n}", result)
+
+	_, err = BuildMutation(s, nil, "unknown", 0)
+	assert.Error(t, err)
+}
+
+func TestBuildQuery(t *testing.T) {
+	t.Parallel()
+
+	s := testQuerySchema()
+
+	result, err := BuildQuery(s, nil, "actor.user", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "query(\n\t$id: Int!,\n) { actor { user(\n\tid: $id,\n) {\n\tmanager {\n\t\tname\n\t}\n\tname\n} } }", result)
+
+	_, err = BuildQuery(s, nil, "actor", 0)
+	assert.EqualError(t, err, "path actor must include at least one field before the endpoint")
+
+	_, err = BuildQuery(s, nil, "actor.unknown", 0)
+	assert.Error(t, err)
+}
+
+func TestParseVariables(t *testing.T) {
+	t.Parallel()
+
+	result, err := ParseVariables(`{"id": 1, "name": "a"}`, []string{"name=b", "tags=[\"x\"]", "flag=true"})
+	require.NoError(t, err)
+
+	expected := map[string]interface{}{
+		"id":   float64(1),
+		"name": "b",
+		"tags": []interface{}{"x"},
+		"flag": true,
+	}
+	assert.Equal(t, expected, result)
+
+	_, err = ParseVariables("", []string{"missing"})
+	assert.EqualError(t, err, `variable "missing" must be in the format name=value`)
+
+	_, err = ParseVariables("[]", nil)
+	assert.Error(t, err)
+}
+
+func TestQuery(t *testing.T) {
+	t.Parallel()
+
+	srv, err := mock.NewServer(testQuerySchema(), nil)
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "tutone-query")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "operation.graphql")
+	require.NoError(t, ioutil.WriteFile(file, []byte("query GetUser($id: Int!) { actor { user(id: $id) { name } } }"), 0644))
+
+	var out bytes.Buffer
+	err = Query(QueryOptions{File: file, Endpoint: ts.URL, Vars: []string{"id=1"}}, &out)
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"data\": {\n    \"actor\": {\n      \"user\": {\n        \"name\": \"name\"\n      }\n    }\n  }\n}\n", out.String())
+
+	// GraphQL errors are printed, and returned as an error
+	out.Reset()
+	err = Query(QueryOptions{File: file, Endpoint: ts.URL}, &out)
+	assert.EqualError(t, err, "1 GraphQL error(s) returned: variable.id: must be defined")
+	assert.Contains(t, out.String(), `"message": "must be defined"`)
+
+	err = Query(QueryOptions{File: file, Mutation: "userRename"}, &out)
+	assert.EqualError(t, err, "exactly one of a file, mutation or path is required")
+}
@@ -0,0 +1,51 @@
+package templates
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	DefaultExportDir = "templates"
+)
+
+var (
+	outputDir string
+	force     bool
+)
+
+var Command = &cobra.Command{
+	Use:   "templates",
+	Short: "Manage the built-in templates",
+	Long: `Manage the built-in templates
+
+The templates of each generator are built into tutone.  They can be
+exported for customization, then used by setting the templateDir of
+the generator, where they take precedence over the built-in templates.
+`,
+}
+
+var exportCommand = &cobra.Command{
+	Use:   "export [generator...]",
+	Short: "Export the built-in templates",
+	Long: `Export the built-in templates
+
+The export command writes the built-in templates of the given
+generators, or all of them, to a directory named after each
+generator within the output directory.  Existing files are only
+overwritten with the --force flag.
+`,
+	Example: "tutone templates export nerdgraphclient --output templates",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := Export(outputDir, args, force); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	Command.AddCommand(exportCommand)
+
+	exportCommand.Flags().StringVarP(&outputDir, "output", "o", DefaultExportDir, "Directory to export the templates to")
+	exportCommand.Flags().BoolVarP(&force, "force", "f", false, "Overwrite existing files")
+}
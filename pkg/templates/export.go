@@ -0,0 +1,109 @@
+package templates
+
+import (
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/newrelic/tutone/internal/codegen"
+	"github.com/newrelic/tutone/internal/filesystem"
+	embedded "github.com/newrelic/tutone/templates"
+)
+
+// Generators returns the names of the generators with built-in templates.
+func Generators() ([]string, error) {
+	entries, err := fs.ReadDir(embedded.FS, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && e.Name() != codegen.PartialsTemplateDir {
+			names = append(names, e.Name())
+		}
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// Export writes the built-in templates of the generators, or all of them when
+// none are given, to a directory for each generator within the outputDir.  The
+// built-in partials are written along with each generator's templates, so the
+// directory can be used as its templateDir.  Existing files are only
+// overwritten when force is set.
+func Export(outputDir string, generators []string, force bool) error {
+	available, err := Generators()
+	if err != nil {
+		return err
+	}
+
+	if len(generators) == 0 {
+		generators = available
+	}
+
+	for _, name := range generators {
+		if !contains(available, name) {
+			return fmt.Errorf("no built-in templates for generator %q, must be one of %v", name, available)
+		}
+	}
+
+	for _, name := range generators {
+		files, err := fs.Glob(embedded.FS, path.Join(name, "*.tmpl"))
+		if err != nil {
+			return err
+		}
+
+		partials, err := fs.Glob(embedded.FS, path.Join(codegen.PartialsTemplateDir, "*.tmpl"))
+		if err != nil {
+			return err
+		}
+
+		dir := filepath.Join(outputDir, name)
+		if err := filesystem.MakeDir(dir, 0775); err != nil {
+			return err
+		}
+
+		for _, f := range append(files, partials...) {
+			content, err := fs.ReadFile(embedded.FS, f)
+			if err != nil {
+				return err
+			}
+
+			file := filepath.Join(dir, path.Base(f))
+
+			if _, err := os.Stat(file); err == nil && !force {
+				return fmt.Errorf("%s already exists, use --force to overwrite it", file)
+			}
+
+			if err := ioutil.WriteFile(file, content, 0644); err != nil {
+				return err
+			}
+
+			log.WithFields(log.Fields{
+				"generator": name,
+				"file":      file,
+			}).Info("exported template")
+		}
+	}
+
+	return nil
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,49 @@
+//go:build unit
+// +build unit
+
+package templates
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "tutone-templates")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	generators, err := Generators()
+	require.NoError(t, err)
+	assert.Contains(t, generators, "typegen")
+	assert.Contains(t, generators, "nerdgraphclient")
+	assert.NotContains(t, generators, "partials")
+
+	require.NoError(t, Export(dir, []string{"typegen"}, false))
+	assert.FileExists(t, filepath.Join(dir, "typegen", "types.go.tmpl"))
+	assert.FileExists(t, filepath.Join(dir, "typegen", "struct.tmpl"))
+	assert.NoDirExists(t, filepath.Join(dir, "nerdgraphclient"))
+
+	// Existing files are only overwritten when forced
+	file := filepath.Join(dir, "typegen", "types.go.tmpl")
+	require.NoError(t, ioutil.WriteFile(file, []byte("custom"), 0644))
+
+	err = Export(dir, nil, false)
+	assert.EqualError(t, err, file+" already exists, use --force to overwrite it")
+
+	require.NoError(t, Export(dir, nil, true))
+	content, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	assert.NotEqual(t, "custom", string(content))
+	assert.FileExists(t, filepath.Join(dir, "nerdgraphclient", "client.go.tmpl"))
+
+	err = Export(dir, []string{"unknown"}, false)
+	assert.Error(t, err)
+}
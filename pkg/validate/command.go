@@ -0,0 +1,83 @@
+package validate
+
+import (
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/newrelic/tutone/internal/config"
+	"github.com/newrelic/tutone/internal/schema"
+	"github.com/newrelic/tutone/pkg/fetch"
+)
+
+var (
+	schemaFile string
+	noSchema   bool
+)
+
+var Command = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate the configuration file",
+	Long: `Validate the configuration file
+
+The validate command decodes your .tutone.yml configuration file,
+and the files it includes and extends, strictly, reporting unknown
+fields, and resolves every reference:
+the generators of each package, and against the cached schema, the
+type names, mutation patterns, query paths, endpoint names and
+skip_fields.  Errors and warnings are printed with their file:line
+position, and the command exits non-zero when there are errors.
+`,
+	Example: "tutone validate --config .tutone.yml",
+	Run: func(cmd *cobra.Command, args []string) {
+		file := viper.ConfigFileUsed()
+
+		var s *schema.Schema
+		if !noSchema {
+			var err error
+			s, err = loadSchema(file)
+			if err != nil {
+				log.Warnf("skipping the schema checks: %s", err)
+			}
+		}
+
+		issues, err := ValidateFile(file, s)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		Print(cmd.OutOrStdout(), issues)
+
+		if Errors(issues) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// loadSchema loads the cached schema from the flag, or the configuration file,
+// which is read leniently so that the schema can be found even when it's
+// invalid.
+func loadSchema(file string) (*schema.Schema, error) {
+	if schemaFile == "" {
+		if cfg, err := config.LoadConfig(file); err == nil {
+			schemaFile = cfg.Cache.SchemaFile
+		}
+	}
+
+	if schemaFile == "" {
+		schemaFile = fetch.DefaultSchemaCacheFile
+	}
+
+	if _, err := os.Stat(schemaFile); err != nil {
+		return nil, err
+	}
+
+	return schema.Load(schemaFile)
+}
+
+func init() {
+	Command.Flags().StringVarP(&schemaFile, "schema", "s", "", "Schema file to resolve references against, defaults to the cached schema file")
+	Command.Flags().BoolVar(&noSchema, "no-schema", false, "Skip the checks against the schema")
+}
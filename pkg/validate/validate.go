@@ -0,0 +1,459 @@
+package validate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/newrelic/tutone/internal/config"
+	"github.com/newrelic/tutone/internal/schema"
+)
+
+// Severity is how serious an Issue is.  Errors cause generation to fail or
+// produce the wrong code, while warnings are likely mistakes.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// builtinGenerators are the names of the generators that don't need a plugin.
+var builtinGenerators = []string{"typegen", "nerdgraphclient", "command", "terraform"}
+
+// Issue is a problem found at a position of the configuration file.
+type Issue struct {
+	File     string
+	Line     int
+	Column   int
+	Severity Severity
+	Message  string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s", i.File, i.Line, i.Column, i.Severity, i.Message)
+}
+
+// ValidateFile validates the configuration file, and the files it includes
+// and extends, decoding each strictly so that unknown fields are reported, and
+// resolving the references between packages and generators of the loaded
+// configuration.  When the schema is not nil, the types, mutations, query
+// paths, endpoints and skipped fields are also resolved against it.  The
+// issues are returned in the order of the files, and an error only when a file
+// can't be read.
+func ValidateFile(file string, s *schema.Schema) ([]Issue, error) {
+	loader := &config.Loader{}
+	merged, loadErr := loader.Load(file)
+
+	files := loader.Files
+	if len(files) == 0 {
+		files = []string{file}
+	}
+
+	var issues []Issue
+
+	for i, f := range files {
+		content, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+
+		v := &validator{file: f, schema: s, merged: merged, main: i == 0}
+		issues = append(issues, v.validate(content, loadErr == nil)...)
+	}
+
+	// The error names the file it came from, which may not have been read.
+	if loadErr != nil && Errors(issues) == 0 {
+		issues = append(issues, Issue{File: file, Severity: SeverityError, Message: loadErr.Error()})
+	}
+
+	return issues, nil
+}
+
+// Validate validates the content of a configuration file, see ValidateFile.
+// The references are resolved within the content, without loading the files
+// it includes or extends.
+func Validate(file string, content []byte, s *schema.Schema) []Issue {
+	v := &validator{file: file, schema: s, main: true}
+
+	return v.validate(content, true)
+}
+
+// Errors returns the number of issues that are errors.
+func Errors(issues []Issue) int {
+	count := 0
+	for _, i := range issues {
+		if i.Severity == SeverityError {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Print writes the issues, followed by a summary.
+func Print(out io.Writer, issues []Issue) {
+	for _, i := range issues {
+		fmt.Fprintln(out, i)
+	}
+
+	errorCount := Errors(issues)
+	fmt.Fprintf(out, "%d error(s), %d warning(s)\n", errorCount, len(issues)-errorCount)
+}
+
+type validator struct {
+	file   string
+	root   *yaml.Node
+	schema *schema.Schema
+	// merged is the configuration loaded with the included and extended files,
+	// where the references are resolved, or nil to resolve them in the file.
+	merged *config.Config
+	// main is set for the file that was loaded, which must have packages.
+	main   bool
+	issues []Issue
+}
+
+// validate decodes the content strictly, and when references is set, resolves
+// the references of its generators and packages.
+func (v *validator) validate(content []byte, references bool) []Issue {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		v.yamlError(err)
+		return v.issues
+	}
+
+	if len(doc.Content) == 0 {
+		v.add(1, 0, SeverityError, "the configuration is empty")
+		return v.issues
+	}
+
+	v.root = doc.Content[0]
+
+	var cfg config.Config
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(&cfg); err != nil {
+		v.yamlError(err)
+
+		// The references can't be resolved from a config that didn't decode.
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return v.sorted()
+		}
+	}
+
+	if references {
+		v.validateConfig(&cfg)
+	}
+
+	return v.sorted()
+}
+
+func (v *validator) add(line int, column int, severity Severity, format string, args ...interface{}) {
+	v.issues = append(v.issues, Issue{
+		File:     v.file,
+		Line:     line,
+		Column:   column,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// addAt adds an issue at the position of the node found at the path, see
+// lookup.
+func (v *validator) addAt(path []interface{}, severity Severity, format string, args ...interface{}) {
+	n := lookup(v.root, path...)
+	v.add(n.Line, n.Column, severity, format, args...)
+}
+
+func (v *validator) sorted() []Issue {
+	sort.SliceStable(v.issues, func(i, j int) bool {
+		if v.issues[i].Line != v.issues[j].Line {
+			return v.issues[i].Line < v.issues[j].Line
+		}
+
+		return v.issues[i].Column < v.issues[j].Column
+	})
+
+	return v.issues
+}
+
+// yamlErrorLine matches the position in the messages of the YAML errors, eg:
+// "line 12: field max_query_depth not found in type config.MutationConfig"
+var yamlErrorLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// yamlError adds an issue for each of the errors of decoding the YAML.
+func (v *validator) yamlError(err error) {
+	messages := []string{err.Error()}
+
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		messages = typeErr.Errors
+	}
+
+	for _, message := range messages {
+		m := yamlErrorLine.FindStringSubmatch(message)
+		if m == nil {
+			v.add(0, 0, SeverityError, "%s", strings.TrimPrefix(message, "yaml: "))
+			continue
+		}
+
+		line, _ := strconv.Atoi(m[1])
+		v.add(line, v.keyColumn(line, m[2]), SeverityError, "%s", m[2])
+	}
+}
+
+// unknownField matches the messages of fields that aren't in the config.
+var unknownField = regexp.MustCompile(`^field (\S+) not found in type`)
+
+// keyColumn finds the column of the unknown field on the line, or zero.
+func (v *validator) keyColumn(line int, message string) int {
+	m := unknownField.FindStringSubmatch(message)
+	if m == nil || v.root == nil {
+		return 0
+	}
+
+	column := 0
+
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		if n.Kind == yaml.MappingNode {
+			for i := 0; i < len(n.Content)-1; i += 2 {
+				if key := n.Content[i]; key.Line == line && key.Value == m[1] {
+					column = key.Column
+				}
+			}
+		}
+
+		for _, c := range n.Content {
+			walk(c)
+		}
+	}
+
+	walk(v.root)
+
+	return column
+}
+
+// lookup returns the node at the path of mapping keys and sequence indexes,
+// or the deepest node of the path that exists.
+func lookup(n *yaml.Node, path ...interface{}) *yaml.Node {
+	for _, p := range path {
+		var next *yaml.Node
+
+		switch key := p.(type) {
+		case string:
+			if n.Kind == yaml.MappingNode {
+				for i := 0; i < len(n.Content)-1; i += 2 {
+					if n.Content[i].Value == key {
+						next = n.Content[i+1]
+					}
+				}
+			}
+		case int:
+			if n.Kind == yaml.SequenceNode && key < len(n.Content) {
+				next = n.Content[key]
+			}
+		}
+
+		if next == nil {
+			return n
+		}
+
+		n = next
+	}
+
+	return n
+}
+
+func (v *validator) validateConfig(cfg *config.Config) {
+	merged := v.merged
+	if merged == nil {
+		merged = cfg
+	}
+
+	if v.main && len(merged.Packages) == 0 {
+		v.addAt([]interface{}{"packages"}, SeverityError, "an array of packages is required")
+	}
+
+	generatorNames := map[string]bool{}
+	for _, g := range merged.Generators {
+		generatorNames[g.Name] = true
+	}
+
+	fileGeneratorNames := map[string]bool{}
+	for i, g := range cfg.Generators {
+		path := []interface{}{"generators", i}
+
+		if g.Name == "" {
+			v.addAt(path, SeverityError, "generator name is required")
+			continue
+		}
+
+		if fileGeneratorNames[g.Name] {
+			v.addAt(append(path, "name"), SeverityError, "generator %s is configured more than once", g.Name)
+		}
+
+		fileGeneratorNames[g.Name] = true
+
+		if g.Plugin == "" && !contains(builtinGenerators, g.Name) {
+			v.addAt(append(path, "name"), SeverityError, "no generator named %s, expected one of %s or a plugin", g.Name, strings.Join(builtinGenerators, ", "))
+		}
+	}
+
+	packageNames := map[string]bool{}
+	for i := range cfg.Packages {
+		pkgConfig := &cfg.Packages[i]
+		path := []interface{}{"packages", i}
+
+		if pkgConfig.Name == "" {
+			v.addAt(path, SeverityError, "package name is required")
+		} else if packageNames[pkgConfig.Name] {
+			v.addAt(append(path, "name"), SeverityError, "package %s is configured more than once", pkgConfig.Name)
+		}
+
+		packageNames[pkgConfig.Name] = true
+
+		// The loaded package has the defaults and interpolated variables.
+		for j := range merged.Packages {
+			if merged.Packages[j].Name == pkgConfig.Name {
+				pkgConfig = &merged.Packages[j]
+				break
+			}
+		}
+
+		if len(pkgConfig.Generators) == 0 {
+			v.addAt(path, SeverityWarning, "package %s has no generators", pkgConfig.Name)
+		}
+
+		for j, name := range pkgConfig.Generators {
+			if !generatorNames[name] {
+				v.addAt(append(path, "generators", j), SeverityError, "no generator named %s in generators", name)
+			}
+		}
+
+		if v.schema != nil {
+			v.validatePackageSchema(pkgConfig, path)
+		}
+	}
+}
+
+// validatePackageSchema resolves the references of the package to the schema.
+func (v *validator) validatePackageSchema(pkgConfig *config.PackageConfig, path []interface{}) {
+	at := func(p ...interface{}) []interface{} {
+		return append(append([]interface{}{}, path...), p...)
+	}
+
+	for i, t := range pkgConfig.Types {
+		schemaType, err := v.schema.LookupTypeByName(t.Name)
+		if err != nil {
+			v.addAt(at("types", i, "name"), SeverityError, "no type named %s in the schema", t.Name)
+			continue
+		}
+
+		for j, f := range t.SkipFields {
+			if !hasField(schemaType, f) {
+				v.addAt(at("types", i, "skip_fields", j), SeverityWarning, "no field named %s on type %s", f, t.Name)
+			}
+		}
+	}
+
+	for i, m := range pkgConfig.Mutations {
+		pattern := m.Name
+		if !strings.HasPrefix(pattern, "^") {
+			pattern = "^" + pattern
+		}
+
+		if !strings.HasSuffix(pattern, "$") {
+			pattern += "$"
+		}
+
+		if _, err := regexp.Compile(pattern); err != nil {
+			v.addAt(at("mutations", i, "name"), SeverityError, "invalid mutation pattern %s: %s", m.Name, err)
+			continue
+		}
+
+		if len(v.schema.LookupMutationsByPattern(m.Name)) == 0 {
+			v.addAt(at("mutations", i, "name"), SeverityError, "no mutation matching %s in the schema", m.Name)
+		}
+	}
+
+	for i, q := range pkgConfig.Queries {
+		endpointType, err := v.schema.LookupTypeByName("RootQueryType")
+		if err != nil {
+			v.addAt(at("queries", i), SeverityError, "%s", err)
+			continue
+		}
+
+		if len(q.Path) > 0 {
+			types, err := v.schema.LookupQueryTypesByFieldPath(q.Path)
+			if err != nil {
+				v.addAt(at("queries", i, "path"), SeverityError, "invalid query path %s: %s", strings.Join(q.Path, "."), err)
+				continue
+			}
+
+			endpointType = types[len(types)-1]
+		}
+
+		for j, e := range q.Endpoints {
+			if !hasField(endpointType, e.Name) {
+				v.addAt(at("queries", i, "endpoints", j, "name"), SeverityError, "no endpoint named %s on type %s", e.Name, endpointType.Name)
+			}
+		}
+	}
+
+	for i, r := range pkgConfig.Resources {
+		inputType, err := v.schema.LookupTypeByName(r.InputType)
+		if err != nil {
+			v.addAt(at("resources", i, "inputType"), SeverityError, "no type named %s in the schema", r.InputType)
+		} else if inputType.Kind != schema.KindInputObject {
+			v.addAt(at("resources", i, "inputType"), SeverityError, "type %s is not an input object", r.InputType)
+		}
+
+		mutations := []struct{ key, name string }{{"create", r.Create}, {"update", r.Update}, {"delete", r.Delete}}
+		for _, m := range mutations {
+			if m.name == "" {
+				continue
+			}
+
+			if _, err := v.schema.LookupMutationByName(m.name); err != nil {
+				v.addAt(at("resources", i, m.key), SeverityError, "no mutation named %s in the schema", m.name)
+			}
+		}
+	}
+}
+
+func hasField(t *schema.Type, name string) bool {
+	for _, f := range t.Fields {
+		if f.Name == name {
+			return true
+		}
+	}
+
+	for _, f := range t.InputFields {
+		if f.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,152 @@
+//go:build unit
+// +build unit
+
+package validate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/tutone/internal/schema"
+)
+
+var testSchema = &schema.Schema{
+	MutationType: &schema.Type{
+		Name:   "RootMutationType",
+		Fields: []schema.Field{{Name: "alertsPolicyCreate"}, {Name: "alertsPolicyDelete"}},
+	},
+	Types: []*schema.Type{
+		{Name: "RootQueryType", Fields: []schema.Field{{Name: "actor", Type: schema.TypeRef{Name: "Actor", Kind: schema.KindObject}}}},
+		{Name: "Actor", Fields: []schema.Field{{Name: "user", Type: schema.TypeRef{Name: "User", Kind: schema.KindObject}}}},
+		{Name: "User", Fields: []schema.Field{{Name: "name"}}},
+	},
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	content := []byte(`packages:
+  - name: alerts
+    generators:
+      - typegen
+      - typgen
+    types:
+      - name: User
+        skip_fields: [name, email]
+      - name: Missing
+    mutations:
+      - name: alertsPolicy.*
+        max_query_depth: 3
+      - name: alertsPolicyUpdate
+    queries:
+      - path: ["actor"]
+        endpoints:
+          - name: user
+          - name: account
+      - path: ["actor", "nope"]
+    resources:
+      - name: newrelic_alert_policy
+        inputType: User
+        create: alertsPolicyCreate
+        delete: alertsPolicyRemove
+  - name: empty
+generators:
+  - name: typegen
+`)
+
+	issues := Validate("tutone.yml", content, testSchema)
+
+	var messages []string
+	for _, i := range issues {
+		messages = append(messages, i.String())
+	}
+
+	assert.Equal(t, []string{
+		"tutone.yml:5:9: error: no generator named typgen in generators",
+		"tutone.yml:8:29: warning: no field named email on type User",
+		"tutone.yml:9:15: error: no type named Missing in the schema",
+		"tutone.yml:12:9: error: field max_query_depth not found in type config.MutationConfig",
+		"tutone.yml:13:15: error: no mutation matching alertsPolicyUpdate in the schema",
+		"tutone.yml:18:19: error: no endpoint named account on type Actor",
+		"tutone.yml:19:15: error: invalid query path actor.nope: no field name nope on type Actor",
+		"tutone.yml:22:20: error: type User is not an input object",
+		"tutone.yml:24:17: error: no mutation named alertsPolicyRemove in the schema",
+		"tutone.yml:25:5: warning: package empty has no generators",
+	}, messages)
+	assert.Equal(t, 8, Errors(issues))
+
+	var out bytes.Buffer
+	Print(&out, issues)
+	assert.Contains(t, out.String(), "8 error(s), 2 warning(s)\n")
+}
+
+func TestValidate_NoSchema(t *testing.T) {
+	t.Parallel()
+
+	content := []byte(`packages:
+  - name: alerts
+    generators: [typegen]
+    types:
+      - name: Missing
+generators:
+  - name: typegen
+  - name: other
+`)
+
+	issues := Validate("tutone.yml", content, nil)
+	require.Len(t, issues, 1)
+	assert.Equal(t, Issue{File: "tutone.yml", Line: 8, Column: 11, Severity: SeverityError, Message: "no generator named other, expected one of typegen, nerdgraphclient, command, terraform or a plugin"}, issues[0])
+
+	issues = Validate("tutone.yml", []byte("packages: [\n"), nil)
+	require.Len(t, issues, 1)
+	assert.Equal(t, 1, issues[0].Line)
+	assert.Equal(t, SeverityError, issues[0].Severity)
+}
+
+func TestValidateFile_Include(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "tutone-validate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	configFile := path.Join(dir, ".tutone.yml")
+	require.NoError(t, ioutil.WriteFile(configFile, []byte(`include: [alerts.yml]
+generators:
+  - name: typegen
+package_defaults:
+  generators: [typegen]
+`), 0644))
+
+	includedFile := path.Join(dir, "alerts.yml")
+	require.NoError(t, ioutil.WriteFile(includedFile, []byte(`packages:
+  - name: alerts
+    generators: [other]
+    max_depth: 2
+`), 0644))
+
+	issues, err := ValidateFile(configFile, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []Issue{
+		{File: includedFile, Line: 3, Column: 18, Severity: SeverityError, Message: "no generator named other in generators"},
+		{File: includedFile, Line: 4, Column: 5, Severity: SeverityError, Message: "field max_depth not found in type config.PackageConfig"},
+	}, issues)
+
+	// Errors only found when loading name the included file
+	require.NoError(t, ioutil.WriteFile(includedFile, []byte(`packages:
+  - name: alerts
+    path: ${TUTONE_TEST_UNSET}
+`), 0644))
+
+	issues, err = ValidateFile(configFile, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []Issue{
+		{File: configFile, Severity: SeverityError, Message: includedFile + ": environment variable TUTONE_TEST_UNSET is not set"},
+	}, issues)
+}
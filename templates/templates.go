@@ -0,0 +1,11 @@
+// Package templates contains the built-in templates of each generator, which
+// are embedded in the binary.
+package templates
+
+import "embed"
+
+// FS contains the built-in templates, in a directory named after each
+// generator, eg: typegen/types.go.tmpl
+//
+//go:embed */*.tmpl
+var FS embed.FS
@@ -0,0 +1,73 @@
+// Package tutone generates Go code from the introspection of a GraphQL schema.
+//
+// It is the library equivalent of `tutone generate`, for tooling that embeds
+// tutone rather than running the binary.  Nothing is read from flags or the
+// environment, other than the API key used to fetch the schema, and errors are
+// returned rather than exiting the process.
+//
+//	cfg, err := tutone.LoadConfig(".tutone.yml")
+//	if err != nil {
+//		return err
+//	}
+//
+//	return tutone.Run(ctx, cfg, tutone.Options{PackageName: "alerts"})
+package tutone
+
+import (
+	"context"
+
+	"github.com/newrelic/tutone/internal/codegen"
+	"github.com/newrelic/tutone/internal/config"
+	"github.com/newrelic/tutone/pkg/generate"
+)
+
+type (
+	// Config is the contents of a configuration file, and the types below are
+	// its parts, for building a Config without a file.
+	Config          = config.Config
+	AuthConfig      = config.AuthConfig
+	CacheConfig     = config.CacheConfig
+	PackageConfig   = config.PackageConfig
+	TypeConfig      = config.TypeConfig
+	MutationConfig  = config.MutationConfig
+	Query           = config.Query
+	EndpointConfig  = config.EndpointConfig
+	Command         = config.Command
+	CommandFlag     = config.CommandFlag
+	Resource        = config.Resource
+	GeneratorConfig = config.GeneratorConfig
+	ScalarConfig    = config.ScalarConfig
+
+	// Options alter a single Run, see generate.GeneratorOptions.
+	Options = generate.GeneratorOptions
+
+	// OutOfDateError is returned by a Run with the Check option when the
+	// generated code differs from the files on disk.
+	OutOfDateError = generate.OutOfDateError
+	// Errors are the failures of several generators, returned by Run.
+	Errors = generate.Errors
+	// CheckResult is a file which differs from the generated code.
+	CheckResult = codegen.CheckResult
+	// FormatError is returned when the output of a template is not valid Go.
+	FormatError = codegen.FormatError
+)
+
+var (
+	// ErrNoPackages is returned when the Config doesn't have any packages.
+	ErrNoPackages = generate.ErrNoPackages
+	// ErrPackageNotFound is returned when the PackageName of the Options isn't
+	// in the Config.
+	ErrPackageNotFound = generate.ErrPackageNotFound
+)
+
+// LoadConfig reads the configuration file.
+func LoadConfig(file string) (*Config, error) {
+	return config.LoadConfig(file)
+}
+
+// Run executes the generators of the configured packages, fetching the schema
+// first when it isn't cached.  Runs may be concurrent, as long as they don't
+// share a manifest, cache or output directory.
+func Run(ctx context.Context, cfg *Config, options Options) error {
+	return generate.Run(ctx, cfg, options)
+}